@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/famendola1/fantasy-discord-bot/providers"
+)
+
+// cliTimeout bounds the requests made by the command line tools, such as
+// --check-config.
+const cliTimeout = 30 * time.Second
+
+// runCheck validates the config file at path, authenticates with the
+// fantasy provider and fetches the league's metadata, writing a report of each
+// step to w. It reports whether every step passed.
+func runCheck(w io.Writer, path string) bool {
+	conf, err := loadConfig(path)
+	if err != nil {
+		fmt.Fprintln(w, "config: FAIL")
+		for _, e := range unwrapJoined(err) {
+			fmt.Fprintf(w, "  %v\n", e)
+		}
+		return false
+	}
+	fmt.Fprintln(w, "config: ok")
+
+	p := providers.NewYahooProvider(&conf.Auth, conf.Game, conf.LeagueID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cliTimeout)
+	defer cancel()
+
+	expiry, err := p.TokenExpiry(ctx)
+	if err != nil {
+		fmt.Fprintf(w, "auth: FAIL\n  %v\n", err)
+		return false
+	}
+	fmt.Fprintf(w, "auth: ok (token expires %s)\n", expiry.Format(time.RFC3339))
+
+	l, err := p.League(ctx)
+	if err != nil {
+		fmt.Fprintf(w, "league: FAIL\n  %v\n", err)
+		return false
+	}
+	fmt.Fprintf(w, "league: ok\n  %s (%s), %s season, %d teams, %s scoring\n",
+		l.Name, l.LeagueKey, l.Season, l.NumTeams, l.ScoringType)
+	return true
+}
+
+// unwrapJoined returns the errors joined in err, including those joined within
+// them, or err itself.
+func unwrapJoined(err error) []error {
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []error{err}
+	}
+	var errs []error
+	for _, e := range joined.Unwrap() {
+		errs = append(errs, unwrapJoined(e)...)
+	}
+	return errs
+}
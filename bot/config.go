@@ -0,0 +1,358 @@
+package main
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/famendola1/fantasy-discord-bot/bot/handlers"
+	"github.com/famendola1/yauth"
+	"gopkg.in/yaml.v3"
+)
+
+// envPrefix is the prefix of the environment variables that override the
+// config file.
+const envPrefix = "FANTASY_BOT"
+
+type config struct {
+	Auth         yauth.YAuth `json:"auth"`
+	Provider     string      `json:"provider"`
+	Game         string      `json:"game"`
+	LeagueID     int         `json:"league_id"`
+	DiscordToken string      `json:"discord_token"`
+	HTTPAddr     string      `json:"http_addr,omitempty"`
+	StoragePath  string      `json:"storage_path,omitempty"`
+
+	Handler handlers.Config `json:"handler"`
+	Posts   postsConfig     `json:"posts"`
+	Feeds   feedsConfig     `json:"feeds"`
+	Log     logConfig       `json:"log"`
+}
+
+// loadConfig reads the config file at path, if any, applies the overrides from
+// the environment and validates the result.
+func loadConfig(path string) (*config, error) {
+	conf, err := readConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := conf.validate(); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// readConfig reads the config file at path, if any, and applies the overrides
+// from the environment without validating the result.
+func readConfig(path string) (*config, error) {
+	var conf config
+	if path != "" {
+		if err := readConfigFile(path, &conf); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+	}
+
+	if _, err := applyEnv(reflect.ValueOf(&conf).Elem(), envPrefix); err != nil {
+		return nil, err
+	}
+	return &conf, nil
+}
+
+// readConfigFile decodes the config file at path into conf. The format is
+// chosen by the file's extension: .yaml/.yml, .toml, or JSON otherwise.
+func readConfigFile(path string, conf *config) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	// YAML and TOML are decoded generically and converted to JSON, so the
+	// config only needs JSON tags and custom types only need to implement
+	// encoding.TextUnmarshaler.
+	var raw map[string]any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &raw)
+	case ".toml":
+		err = toml.Unmarshal(content, &raw)
+	default:
+		return strictUnmarshal(content, conf)
+	}
+	if err != nil {
+		return err
+	}
+
+	content, err = json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	return strictUnmarshal(content, conf)
+}
+
+// writeConfigFile writes conf to the file at path, in the format chosen by the
+// file's extension as in readConfigFile.
+func writeConfigFile(path string, conf *config) error {
+	content, err := json.MarshalIndent(conf, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".yaml" || ext == ".yml" || ext == ".toml" {
+		var raw map[string]any
+		dec := json.NewDecoder(bytes.NewReader(content))
+		dec.UseNumber()
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+		normalize(raw)
+
+		var buf bytes.Buffer
+		if ext == ".toml" {
+			err = toml.NewEncoder(&buf).Encode(raw)
+		} else {
+			err = yaml.NewEncoder(&buf).Encode(raw)
+		}
+		if err != nil {
+			return err
+		}
+		content = buf.Bytes()
+	}
+
+	// The config holds secrets, so only the owner may read it.
+	return os.WriteFile(path, content, 0o600)
+}
+
+// normalize prepares m, decoded from JSON, to be encoded as YAML or TOML. Null
+// values are removed, since TOML has no null, and numbers are converted to
+// integers where possible so that they aren't written as floats or strings.
+func normalize(m map[string]any) {
+	for k, v := range m {
+		switch v := v.(type) {
+		case nil:
+			delete(m, k)
+		case json.Number:
+			if n, err := v.Int64(); err == nil {
+				m[k] = n
+			} else {
+				m[k], _ = v.Float64()
+			}
+		case map[string]any:
+			normalize(v)
+		}
+	}
+}
+
+// strictUnmarshal decodes the JSON in content into conf, rejecting unknown
+// fields so that typos in the config file are reported.
+func strictUnmarshal(content []byte, conf *config) error {
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.DisallowUnknownFields()
+	return dec.Decode(conf)
+}
+
+// validate returns an error listing every missing or invalid field.
+func (c *config) validate() error {
+	var errs []error
+	missing := func(field string) {
+		errs = append(errs, fmt.Errorf("%s is required", field))
+	}
+
+	if c.Provider != "yahoo" {
+		errs = append(errs, fmt.Errorf("provider must be \"yahoo\", not %q", c.Provider))
+	}
+	if c.Game == "" {
+		missing("game")
+	}
+	if c.LeagueID <= 0 {
+		errs = append(errs, fmt.Errorf("league_id must be positive, not %d", c.LeagueID))
+	}
+	if c.DiscordToken == "" {
+		missing("discord_token")
+	}
+	if err := c.validateAuth(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.Handler.Prefix != "" {
+		if err := handlers.ValidatePrefix(c.Handler.Prefix); err != nil {
+			errs = append(errs, fmt.Errorf("handler.prefix: %w", err))
+		}
+	}
+	for id, g := range c.Handler.Guilds {
+		if g.Prefix != "" {
+			if err := handlers.ValidatePrefix(g.Prefix); err != nil {
+				errs = append(errs, fmt.Errorf("handler.guilds.%s.prefix: %w", id, err))
+			}
+		}
+		if err := g.Channels.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("handler.guilds.%s.channels: %w", id, err))
+		}
+	}
+	for comm, cd := range c.Handler.Cooldowns {
+		if cd.User < 0 || cd.Channel < 0 {
+			errs = append(errs, fmt.Errorf("handler.cooldowns.%s must not be negative", comm))
+		}
+	}
+	if c.Handler.Timeout < 0 {
+		errs = append(errs, errors.New("handler.timeout must not be negative"))
+	}
+	if err := c.Posts.validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.Feeds.validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := newLogger(c.Log); err != nil {
+		errs = append(errs, fmt.Errorf("log: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateAuth returns an error listing every missing auth field.
+func (c *config) validateAuth() error {
+	var errs []error
+	missing := func(field string) {
+		errs = append(errs, fmt.Errorf("%s is required", field))
+	}
+
+	if c.Auth.ClientID == "" {
+		missing("auth.client_id")
+	}
+	if c.Auth.ClientSecret == "" {
+		missing("auth.client_secret")
+	}
+	if c.Auth.Token == nil || c.Auth.Token.RefreshToken == "" {
+		missing("auth.token.refresh_token")
+	}
+	return errors.Join(errs...)
+}
+
+// providerChanged reports whether the provider must be recreated to apply the
+// config b in place of a.
+func providerChanged(a, b *config) bool {
+	return a.Provider != b.Provider ||
+		a.Game != b.Game ||
+		a.LeagueID != b.LeagueID ||
+		a.Auth.ClientID != b.Auth.ClientID ||
+		a.Auth.ClientSecret != b.Auth.ClientSecret ||
+		a.Auth.Token.RefreshToken != b.Auth.Token.RefreshToken
+}
+
+// applyEnv overrides the fields of the struct v with environment variables.
+// Each field's variable is named after its JSON key, upper cased and prefixed
+// with the names of its parents, e.g. FANTASY_BOT_AUTH_CLIENT_ID. The value can
+// also be read from the file named by the variable with a _FILE suffix, which
+// is how secrets are usually provided to containers. Fields that aren't
+// strings, numbers, booleans or durations are set as JSON. It reports whether
+// any field was set, and returns an error listing every invalid override.
+func applyEnv(v reflect.Value, prefix string) (bool, error) {
+	set := false
+	var errs []error
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" || !t.Field(i).IsExported() {
+			continue
+		}
+		key := prefix + "_" + strings.ToUpper(name)
+		field := v.Field(i)
+
+		val, ok, err := lookupEnv(key)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if ok {
+			if err := setField(field, val); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", key, err))
+				continue
+			}
+			set = true
+			continue
+		}
+
+		if isText(field) {
+			continue
+		}
+
+		switch {
+		case field.Kind() == reflect.Struct:
+			s, err := applyEnv(field, key)
+			errs = append(errs, err)
+			set = set || s
+		case field.Kind() == reflect.Pointer && field.Type().Elem().Kind() == reflect.Struct:
+			// Only allocate the struct if one of its fields is overridden.
+			elem := reflect.New(field.Type().Elem())
+			if !field.IsNil() {
+				elem.Elem().Set(field.Elem())
+			}
+			s, err := applyEnv(elem.Elem(), key)
+			errs = append(errs, err)
+			if s {
+				field.Set(elem)
+				set = true
+			}
+		}
+	}
+	return set, errors.Join(errs...)
+}
+
+// lookupEnv returns the value of the environment variable key, or the contents
+// of the file named by key_FILE.
+func lookupEnv(key string) (string, bool, error) {
+	if val, ok := os.LookupEnv(key); ok {
+		return val, true, nil
+	}
+
+	path, ok := os.LookupEnv(key + "_FILE")
+	if !ok {
+		return "", false, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("%s_FILE: %w", key, err)
+	}
+	return strings.TrimRight(string(content), "\r\n"), true, nil
+}
+
+// isText reports whether field is set from text rather than from its fields.
+func isText(field reflect.Value) bool {
+	_, ok := field.Addr().Interface().(encoding.TextUnmarshaler)
+	return ok
+}
+
+// setField parses val into field.
+func setField(field reflect.Value, val string) error {
+	if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(val))
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(val)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	default:
+		return json.Unmarshal([]byte(val), field.Addr().Interface())
+	}
+	return nil
+}
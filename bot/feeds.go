@@ -0,0 +1,412 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/famendola1/fantasy-discord-bot/bot/handlers"
+	"github.com/famendola1/fantasy-discord-bot/providers"
+	"github.com/famendola1/fantasy-discord-bot/scheduler"
+	"github.com/famendola1/fantasy-discord-bot/storage"
+)
+
+const (
+	// defaultFeedInterval is how often feeds without an interval are polled.
+	defaultFeedInterval = 5 * time.Minute
+
+	// minFeedInterval keeps feeds from polling the provider too often.
+	minFeedInterval = time.Minute
+)
+
+// injuryStatuses are the player statuses that the injuries feed posts.
+var injuryStatuses = map[string]bool{"INJ": true, "O": true, "GTD": true, "DTD": true}
+
+// feedsConfig configures the league activity the bot posts as it happens.
+type feedsConfig struct {
+	// Transactions posts the league's adds, drops, trades and waiver claims.
+	Transactions *feedConfig `json:"transactions,omitempty"`
+	// Injuries posts changes to the injury status of rostered players.
+	Injuries *feedConfig `json:"injuries,omitempty"`
+}
+
+// feedConfig configures where a feed is posted and how often it is polled.
+type feedConfig struct {
+	// Channel is the ID of the channel to post in.
+	Channel string `json:"channel"`
+	// Interval is how often the provider is polled. Defaults to 5m.
+	Interval handlers.Duration `json:"interval,omitempty"`
+	// PendingTrades also posts trades when they are accepted and pending
+	// review, mentioning the commissioner role, and when they are vetoed.
+	// Only used by the transactions feed.
+	PendingTrades bool `json:"pending_trades,omitempty"`
+}
+
+// pendingTrade is an accepted trade waiting to be processed, as kept in
+// storage.
+type pendingTrade struct {
+	// Received describes what each team of the trade receives.
+	Received string `json:"received"`
+	// Players are the sorted names of the traded players, which identify the
+	// trade once it is processed, since it gets a new key.
+	Players []string `json:"players"`
+	// Missing is the number of polls in a row the trade wasn't pending.
+	Missing int `json:"missing,omitempty"`
+}
+
+// vetoPolls is the number of polls an accepted trade must be missing from the
+// pending trades, without being processed, to be considered vetoed. Waiting
+// for a second poll covers a processed trade showing up late.
+const vetoPolls = 2
+
+// schedule returns the polling schedule of the feed.
+func (c *feedConfig) schedule() scheduler.Interval {
+	if c.Interval == 0 {
+		return scheduler.Interval(defaultFeedInterval)
+	}
+	return scheduler.Interval(c.Interval)
+}
+
+// validate returns an error listing every invalid field of the feed.
+func (c *feedConfig) validate(name string) error {
+	var errs []error
+	if c.Channel == "" {
+		errs = append(errs, fmt.Errorf("feeds.%s.channel is required", name))
+	}
+	if c.Interval != 0 && time.Duration(c.Interval) < minFeedInterval {
+		errs = append(errs, fmt.Errorf("feeds.%s.interval must be at least %s", name, minFeedInterval))
+	}
+	return errors.Join(errs...)
+}
+
+// validate returns an error listing every invalid feed.
+func (c *feedsConfig) validate() error {
+	var errs []error
+	if c.Transactions != nil {
+		errs = append(errs, c.Transactions.validate("transactions"))
+	}
+	if c.Injuries != nil {
+		errs = append(errs, c.Injuries.validate("injuries"))
+		if c.Injuries.PendingTrades {
+			errs = append(errs, errors.New("feeds.injuries.pending_trades is only supported by the transactions feed"))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// feedJobs returns the jobs polling the configured feeds. Like posts, they use
+// the provider held by settings when they run.
+func feedJobs(conf feedsConfig, dg *discordgo.Session, settings *handlers.Settings, store *storage.Store) []scheduler.Job {
+	var jobs []scheduler.Job
+	if c := conf.Transactions; c != nil {
+		jobs = append(jobs, scheduler.Job{
+			Name:     "transactions",
+			Schedule: c.schedule(),
+			Run: func(ctx context.Context) error {
+				return pollTransactions(ctx, dg, settings, store, c)
+			},
+		})
+	}
+	if c := conf.Injuries; c != nil {
+		jobs = append(jobs, scheduler.Job{
+			Name:     "injuries",
+			Schedule: c.schedule(),
+			Run: func(ctx context.Context) error {
+				return pollInjuries(ctx, dg, settings, store, c)
+			},
+		})
+	}
+	return jobs
+}
+
+// feedProvider returns the current provider if it has feeds.
+func feedProvider(settings *handlers.Settings) (providers.FeedProvider, error) {
+	fp, ok := settings.Provider().(providers.FeedProvider)
+	if !ok {
+		return nil, errors.New("the provider doesn't support feeds")
+	}
+	return fp, nil
+}
+
+// pollTransactions posts the transactions that weren't posted to the channel
+// yet, oldest first, and the changes to pending trades if configured. The keys
+// of the posted transactions are kept in store so that they aren't posted
+// again after a restart. The first poll only records the existing
+// transactions, rather than posting the league's history.
+func pollTransactions(ctx context.Context, dg *discordgo.Session, settings *handlers.Settings, store *storage.Store, c *feedConfig) error {
+	channelID := c.Channel
+	fp, err := feedProvider(settings)
+	if err != nil {
+		return err
+	}
+	txs, err := fp.Transactions(ctx)
+	if err != nil {
+		return err
+	}
+
+	key := "feeds/transactions/" + channelID
+	var posted []string
+	found, err := store.Get(key, &posted)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(posted))
+	for _, k := range posted {
+		seen[k] = true
+	}
+
+	// Only the keys of the transactions Yahoo still returns are kept, since
+	// older ones can't come back. If a post fails, the rest are left for the
+	// next poll.
+	var keep []string
+	var sendErr error
+	for i := len(txs) - 1; i >= 0; i-- {
+		tx := txs[i]
+		if found && !seen[tx.Key] {
+			if sendErr != nil {
+				continue
+			}
+			if _, sendErr = dg.ChannelMessageSend(channelID, tx.Format()); sendErr != nil {
+				continue
+			}
+		}
+		keep = append(keep, tx.Key)
+	}
+	if !found {
+		slog.Info("starting transaction feed", "channel", channelID, "existing", len(keep))
+	}
+
+	if err := store.Put(key, keep); err != nil {
+		return err
+	}
+	if sendErr != nil || !c.PendingTrades {
+		return sendErr
+	}
+	return pollPendingTrades(ctx, dg, settings, store, fp, channelID, txs)
+}
+
+// tradedPlayers returns the sorted names of the players traded in tx.
+func tradedPlayers(tx providers.Transaction) []string {
+	players := make([]string, len(tx.Moves))
+	for i, m := range tx.Moves {
+		players[i] = m.Player
+	}
+	sort.Strings(players)
+	return players
+}
+
+// pollPendingTrades posts the trades that were accepted and are pending
+// review, mentioning the commissioner role, and the accepted trades that were
+// vetoed or cancelled. Processed trades are posted as transactions, which are
+// in txs. Like transactions, the first poll only records the pending trades.
+func pollPendingTrades(ctx context.Context, dg *discordgo.Session, settings *handlers.Settings, store *storage.Store, fp providers.FeedProvider, channelID string, txs []providers.Transaction) error {
+	trades, err := fp.PendingTrades(ctx)
+	if err != nil {
+		return err
+	}
+
+	key := "feeds/trades/" + channelID
+	var tracked map[string]pendingTrade
+	found, err := store.Get(key, &tracked)
+	if err != nil {
+		return err
+	}
+	if tracked == nil {
+		tracked = make(map[string]pendingTrade)
+	}
+
+	var sendErr error
+	accepted := make(map[string]bool)
+	for _, t := range trades {
+		if t.Status != "accepted" {
+			continue
+		}
+		accepted[t.Key] = true
+		if p, ok := tracked[t.Key]; ok {
+			p.Missing = 0
+			tracked[t.Key] = p
+			continue
+		}
+		if found {
+			if sendErr = sendTradeReview(dg, settings, channelID, t); sendErr != nil {
+				break
+			}
+		}
+		tracked[t.Key] = pendingTrade{Received: t.Received(), Players: tradedPlayers(t)}
+	}
+
+	processed := make(map[string]bool)
+	for _, tx := range txs {
+		if tx.Type == "trade" {
+			processed[strings.Join(tradedPlayers(tx), "\n")] = true
+		}
+	}
+	for k, p := range tracked {
+		if accepted[k] || sendErr != nil {
+			continue
+		}
+		if processed[strings.Join(p.Players, "\n")] {
+			delete(tracked, k)
+			continue
+		}
+		if p.Missing++; p.Missing < vetoPolls {
+			tracked[k] = p
+			continue
+		}
+		if _, sendErr = dg.ChannelMessageSend(channelID, "**Trade vetoed or cancelled:** "+p.Received); sendErr == nil {
+			delete(tracked, k)
+		}
+	}
+
+	if err := store.Put(key, tracked); err != nil {
+		return err
+	}
+	return sendErr
+}
+
+// sendTradeReview posts an accepted trade to the channel, mentioning the
+// commissioner role of its guild so that they review it.
+func sendTradeReview(dg *discordgo.Session, settings *handlers.Settings, channelID string, t providers.Transaction) error {
+	msg := &discordgo.MessageSend{
+		Content:         t.Format(),
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}
+
+	ch, err := dg.State.Channel(channelID)
+	if err != nil {
+		ch, err = dg.Channel(channelID)
+	}
+	if err != nil {
+		slog.Warn("error looking up the guild of the transactions channel", "channel", channelID, "error", err)
+	} else if role := settings.Config().GuildCommissionerRole(ch.GuildID); role != "" {
+		msg.Content += " <@&" + role + ">"
+		msg.AllowedMentions.Roles = []string{role}
+	}
+
+	_, err = dg.ChannelMessageSendComplex(channelID, msg)
+	return err
+}
+
+// injuryChange describes the change to the status of a rostered player, or
+// returns "" if it isn't posted: only changes to and from injuryStatuses are.
+func injuryChange(p providers.RosteredPlayer, prev string) string {
+	if p.Status == prev || (!injuryStatuses[p.Status] && !injuryStatuses[prev]) {
+		return ""
+	}
+
+	who := fmt.Sprintf("**%s** (%s, %s)", p.Name, p.Team, p.Position)
+	if !injuryStatuses[p.Status] {
+		return fmt.Sprintf("%s is no longer listed as %s.", who, prev)
+	}
+	msg := fmt.Sprintf("%s is now **%s**", who, p.Status)
+	if p.StatusFull != "" && p.StatusFull != p.Status {
+		msg += " (" + p.StatusFull + ")"
+	}
+	if p.InjuryNote != "" {
+		msg += ": " + p.InjuryNote
+	}
+	return msg + "."
+}
+
+// pollInjuries posts the changes to the injury status of rostered players to
+// the channel, and sends them to the owners of their teams. The statuses are
+// kept in store to find the changes across restarts. Players that join a
+// roster aren't posted until their status changes, and the first poll only
+// records the statuses.
+func pollInjuries(ctx context.Context, dg *discordgo.Session, settings *handlers.Settings, store *storage.Store, c *feedConfig) error {
+	fp, err := feedProvider(settings)
+	if err != nil {
+		return err
+	}
+	players, err := fp.RosteredPlayers(ctx)
+	if err != nil {
+		return err
+	}
+
+	key := "feeds/injuries/" + c.Channel
+	var prev map[string]string
+	found, err := store.Get(key, &prev)
+	if err != nil {
+		return err
+	}
+
+	statuses := make(map[string]string, len(players))
+	var changes []injuryUpdate
+	for _, p := range players {
+		statuses[p.Key] = p.Status
+		before, ok := prev[p.Key]
+		if !found || !ok {
+			continue
+		}
+		if change := injuryChange(p, before); change != "" {
+			changes = append(changes, injuryUpdate{player: p, prev: before, text: change})
+		}
+	}
+
+	// Only the statuses of the players whose changes were posted are saved,
+	// so that the rest are posted on the next poll if this one fails. Each
+	// message holds as many whole changes as fit, and a change too long for a
+	// message of its own is split over several.
+	posted := 0
+	var sendErr error
+	for posted < len(changes) {
+		msg, n := changes[posted].text, 1
+		for posted+n < len(changes) && len(msg)+len("\n")+len(changes[posted+n].text) <= handlers.MaxMessageLength {
+			msg += "\n" + changes[posted+n].text
+			n++
+		}
+		if _, sendErr = handlers.SendMessage(dg, c.Channel, msg); sendErr != nil {
+			break
+		}
+		posted += n
+	}
+	for _, ch := range changes[posted:] {
+		statuses[ch.player.Key] = ch.prev
+	}
+
+	// Owners linked to teams with the link command are also sent the changes
+	// to their players.
+	byOwner := make(map[string][]string)
+	for _, ch := range changes[:posted] {
+		user, err := handlers.TeamOwner(store, ch.player.TeamKey)
+		if err != nil {
+			slog.Warn("error looking up team owner", "team", ch.player.Team, "error", err)
+		} else if user != "" {
+			byOwner[user] = append(byOwner[user], ch.text)
+		}
+	}
+	for user, changes := range byOwner {
+		if err := sendDM(dg, user, changes); err != nil {
+			slog.Warn("error sending injury alert to team owner", "user", user, "error", err)
+		}
+	}
+
+	if err := store.Put(key, statuses); err != nil {
+		return errors.Join(sendErr, err)
+	}
+	return sendErr
+}
+
+// injuryUpdate is a change to the status of a rostered player to post.
+type injuryUpdate struct {
+	player providers.RosteredPlayer
+	// prev is the player's status before the change.
+	prev string
+	text string
+}
+
+// sendDM sends the lines to the user in a direct message.
+func sendDM(dg *discordgo.Session, userID string, lines []string) error {
+	ch, err := dg.UserChannelCreate(userID)
+	if err != nil {
+		return err
+	}
+	_, err = handlers.SendMessage(dg, ch.ID, strings.Join(lines, "\n"))
+	return err
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// ChannelConfig restricts the channels of a guild the bot listens in. Messages
+// in a thread are treated as if they were in its parent channel, unless the
+// thread is listed itself.
+type ChannelConfig struct {
+	// Allow lists the IDs of the only channels the bot listens in. If empty,
+	// the bot listens in every channel not in Deny.
+	Allow []string `json:"allow,omitempty"`
+	// Deny lists the IDs of channels the bot ignores.
+	Deny []string `json:"deny,omitempty"`
+	// Commands maps a command name, without the prefix, to the IDs of the
+	// only channels it can be run in.
+	Commands map[string][]string `json:"commands,omitempty"`
+}
+
+// channelIDs returns the ID of the channel and, if it is a thread, of its
+// parent channel. Channels missing from the state are fetched from Discord.
+func channelIDs(s *discordgo.Session, channelID string) []string {
+	ids := []string{channelID}
+	ch, err := s.State.Channel(channelID)
+	if err != nil {
+		ch, err = s.Channel(channelID)
+	}
+	if err == nil && ch.IsThread() && ch.ParentID != "" {
+		ids = append(ids, ch.ParentID)
+	}
+	return ids
+}
+
+// contains reports whether any of the ids is in list.
+func contains(list []string, ids ...string) bool {
+	for _, item := range list {
+		for _, id := range ids {
+			if item == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// listening reports whether the bot listens in the channel of the message.
+func listening(s *discordgo.Session, m *discordgo.MessageCreate, cfg Config) bool {
+	g, ok := cfg.Guilds[m.GuildID]
+	if !ok || (len(g.Channels.Allow) == 0 && len(g.Channels.Deny) == 0) {
+		return true
+	}
+
+	// A thread's own entry takes precedence over its parent's.
+	for _, id := range channelIDs(s, m.ChannelID) {
+		if contains(g.Channels.Allow, id) {
+			return true
+		}
+		if contains(g.Channels.Deny, id) {
+			return false
+		}
+	}
+	return len(g.Channels.Allow) == 0
+}
+
+// commandChannels returns the channels the command is confined to in the
+// channel of the message, or nil if it can be run there.
+func commandChannels(s *discordgo.Session, m *discordgo.MessageCreate, cfg Config, comm string) []string {
+	channels := cfg.Guilds[m.GuildID].Channels.Commands[comm]
+	if len(channels) == 0 || contains(channels, channelIDs(s, m.ChannelID)...) {
+		return nil
+	}
+	return channels
+}
+
+// wrongChannel tells the author of the message where the command can be run.
+func wrongChannel(s *discordgo.Session, m *discordgo.MessageCreate, prefix, comm string, channels []string) {
+	mentions := make([]string, len(channels))
+	for i, id := range channels {
+		mentions[i] = "<#" + id + ">"
+	}
+	s.ChannelMessageSendReply(m.ChannelID,
+		fmt.Sprintf("%s%s can only be run in %s.", prefix, comm, joinOr(mentions)),
+		m.Reference())
+}
+
+// Validate returns an error if a channel is both allowed and denied.
+func (c ChannelConfig) Validate() error {
+	for _, id := range c.Allow {
+		if contains(c.Deny, id) {
+			return fmt.Errorf("channel %s is both allowed and denied", id)
+		}
+	}
+	return nil
+}
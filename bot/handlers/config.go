@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/famendola1/fantasy-discord-bot/providers"
+)
+
+// Config configures the MessageCreate handler.
+type Config struct {
+	// Prefix is the prefix of commands. Defaults to "!".
+	Prefix string `json:"prefix,omitempty"`
+	// Guilds overrides the config for the guild with the given ID.
+	Guilds map[string]GuildConfig `json:"guilds,omitempty"`
+	// Permissions maps a command name, without the prefix, to who can run it.
+	// Admin commands are restricted to admins and the commissioner by default.
+	Permissions map[string]Permission `json:"permissions,omitempty"`
+	// CommissionerRole is the ID of the league commissioner's Discord role.
+	CommissionerRole string `json:"commissioner_role,omitempty"`
+	// Cooldowns maps a command name, without the prefix, to its cooldown.
+	Cooldowns map[string]Cooldown `json:"cooldowns,omitempty"`
+	// ExemptRole is the ID of a Discord role whose members ignore cooldowns.
+	ExemptRole string `json:"exempt_role,omitempty"`
+	// Timeout is the maximum time a command may take.
+	Timeout Duration `json:"timeout,omitempty"`
+}
+
+// GuildConfig configures the handler for a single guild.
+type GuildConfig struct {
+	// Prefix is the prefix of commands in the guild. It can also be changed
+	// with the prefix command, which takes precedence.
+	Prefix string `json:"prefix,omitempty"`
+	// Permissions overrides the permissions of commands in the guild.
+	Permissions map[string]Permission `json:"permissions,omitempty"`
+	// CommissionerRole overrides the commissioner role in the guild.
+	CommissionerRole string `json:"commissioner_role,omitempty"`
+	// Channels restricts the channels the bot listens in.
+	Channels ChannelConfig `json:"channels"`
+}
+
+// Settings holds the provider and config used to handle commands. They can be
+// replaced while the bot is running, e.g. when the config file is reloaded.
+type Settings struct {
+	v atomic.Pointer[settings]
+}
+
+type settings struct {
+	provider providers.MessageCreateProvider
+	config   Config
+	timeout  time.Duration
+}
+
+// NewSettings returns Settings holding the given provider and config.
+func NewSettings(p providers.MessageCreateProvider, cfg Config) *Settings {
+	s := &Settings{}
+	s.Store(p, cfg)
+	return s
+}
+
+// Store replaces the provider and config. Commands already running keep using
+// the old ones.
+func (s *Settings) Store(p providers.MessageCreateProvider, cfg Config) {
+	timeout := time.Duration(cfg.Timeout)
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	s.v.Store(&settings{provider: p, config: cfg, timeout: timeout})
+}
+
+// Provider returns the current provider.
+func (s *Settings) Provider() providers.MessageCreateProvider {
+	return s.load().provider
+}
+
+// Config returns the current config.
+func (s *Settings) Config() Config {
+	return s.load().config
+}
+
+func (s *Settings) load() *settings {
+	return s.v.Load()
+}
+
+// Duration is a time.Duration that is configured as a string such as "30s".
+type Duration time.Duration
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"sync"
+	"time"
+)
+
+// Cooldown is the minimum time between uses of a command.
+type Cooldown struct {
+	// User is the cooldown for each user.
+	User Duration `json:"user"`
+	// Channel is the cooldown for each channel.
+	Channel Duration `json:"channel"`
+}
+
+// cooldownTracker tracks when each command was last used by each user and in
+// each channel.
+type cooldownTracker struct {
+	mu        sync.Mutex
+	expires   map[string]time.Time
+	lastPrune time.Time
+}
+
+func newCooldownTracker() *cooldownTracker {
+	return &cooldownTracker{
+		expires: make(map[string]time.Time),
+	}
+}
+
+// check returns how long the user must wait before running the command in the
+// channel, given the configured cooldowns, or 0 if the command can be run. The
+// use of the command is recorded separately, so that commands rejected for
+// their arguments don't start a cooldown. The cooldowns are passed in rather
+// than kept by the tracker so that uses are still tracked when the config is
+// reloaded.
+func (c *cooldownTracker) check(cooldowns map[string]Cooldown, comm, userID, channelID string) time.Duration {
+	if _, ok := cooldowns[comm]; !ok {
+		return 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	c.prune(now)
+
+	userKey := comm + "/user/" + userID
+	channelKey := comm + "/channel/" + channelID
+
+	var wait time.Duration
+	if exp := c.expires[userKey]; exp.After(now) {
+		wait = exp.Sub(now)
+	}
+	if exp := c.expires[channelKey]; exp.After(now) && exp.Sub(now) > wait {
+		wait = exp.Sub(now)
+	}
+	return wait
+}
+
+// record records a use of the command by the user in the channel, starting its
+// cooldowns.
+func (c *cooldownTracker) record(cooldowns map[string]Cooldown, comm, userID, channelID string) {
+	cd, ok := cooldowns[comm]
+	if !ok {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	userKey := comm + "/user/" + userID
+	channelKey := comm + "/channel/" + channelID
+	if cd.User > 0 {
+		c.expires[userKey] = now.Add(time.Duration(cd.User))
+	}
+	if cd.Channel > 0 {
+		c.expires[channelKey] = now.Add(time.Duration(cd.Channel))
+	}
+}
+
+// prune removes expired cooldowns, at most once a minute.
+func (c *cooldownTracker) prune(now time.Time) {
+	if now.Sub(c.lastPrune) < time.Minute {
+		return
+	}
+	c.lastPrune = now
+
+	for key, exp := range c.expires {
+		if !exp.After(now) {
+			delete(c.expires, key)
+		}
+	}
+}
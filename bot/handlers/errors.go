@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/famendola1/fantasy-discord-bot/providers"
+)
+
+// maxSuggestions is the maximum number of suggestions included in a message.
+const maxSuggestions = 5
+
+// errorMessage returns a message explaining err to the user that ran comm with
+// the given prefix. The raw error is not included, since it may contain
+// responses from the provider that make no sense to users.
+func errorMessage(prefix, comm string, err error) string {
+	var perr *providers.Error
+	if !errors.As(err, &perr) {
+		perr = &providers.Error{}
+	}
+
+	switch {
+	case errors.Is(err, providers.ErrTeamNotFound):
+		return fmt.Sprintf("I couldn't find a team named %q.%s Team names are listed in %sstandings.",
+			perr.Subject, didYouMean(perr.Suggestions), prefix)
+	case errors.Is(err, providers.ErrPlayerNotFound):
+		return fmt.Sprintf("I couldn't find a player named %q.%s", perr.Subject, didYouMean(perr.Suggestions))
+	case errors.Is(err, providers.ErrAmbiguousName):
+		return fmt.Sprintf("%q matches more than one player: %s. Try their full name.",
+			perr.Subject, strings.Join(truncate(perr.Suggestions), ", "))
+	case errors.Is(err, providers.ErrInvalidWeek):
+		week := "That week"
+		if perr.Subject != "" {
+			week = "Week " + perr.Subject
+		}
+		// Only the provider's own description of the season is shown, not
+		// errors from Yahoo.
+		var rerr *providers.WeekRangeError
+		if errors.As(perr.Err, &rerr) {
+			return fmt.Sprintf("%s isn't part of this season, %s.", week, rerr)
+		}
+		return week + " isn't part of this season."
+	case errors.Is(err, providers.ErrInvalidArgs):
+		reason := "invalid argument"
+		if perr.Err != nil {
+			reason = perr.Err.Error()
+		}
+		return fmt.Sprintf("Error: %s (%q). See %shelp for usage of %s%s.", reason, perr.Subject, prefix, prefix, comm)
+	case errors.Is(err, providers.ErrNoGames):
+		return fmt.Sprintf("There are no stats for %s. There were no games, or they haven't started yet.", perr.Subject)
+	case errors.Is(err, providers.ErrAuthExpired):
+		return "The bot's Yahoo authorization has expired. Ask an admin to refresh the bot's Yahoo token."
+	case errors.Is(err, providers.ErrBusy):
+		return "Yahoo is busy right now, try again in a minute."
+	case errors.Is(err, context.DeadlineExceeded):
+		return fmt.Sprintf("%s%s took too long, try again later.", prefix, comm)
+	case errors.Is(err, providers.ErrUnavailable):
+		return "Yahoo Fantasy is unavailable right now, try again later."
+	default:
+		return fmt.Sprintf("Something went wrong running %s%s, try again later.", prefix, comm)
+	}
+}
+
+// didYouMean formats suggestions as a question, or returns "" if there are
+// none.
+func didYouMean(suggestions []string) string {
+	if len(suggestions) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" Did you mean %s?", strings.Join(truncate(suggestions), " or "))
+}
+
+func truncate(suggestions []string) []string {
+	if len(suggestions) > maxSuggestions {
+		return suggestions[:maxSuggestions]
+	}
+	return suggestions
+}
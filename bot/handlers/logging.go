@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"time"
+
+	"github.com/famendola1/fantasy-discord-bot/metrics"
+)
+
+// Outcomes of a command, as recorded in the logs.
+const (
+	outcomeOK       = "ok"
+	outcomeError    = "error"
+	outcomeUsage    = "usage"
+	outcomeCooldown = "cooldown"
+	outcomeDenied   = "denied"
+	outcomeUnknown  = "unknown"
+)
+
+// newRequestID returns a random ID used to correlate the logs of a command.
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// commandRecord collects what happened while running a command so that it
+// can be logged once the command is done.
+type commandRecord struct {
+	command       string
+	logger        *slog.Logger
+	start         time.Time
+	outcome       string
+	err           error
+	upstreamCalls func() int64
+}
+
+// log writes the record to the logger and records its metrics. Unknown commands
+// are only logged at debug level, since they are usually meant for other bots.
+func (r *commandRecord) log() {
+	latency := time.Since(r.start)
+	if r.outcome != outcomeUnknown {
+		metrics.ObserveCommand(r.command, r.outcome, latency)
+	}
+
+	attrs := []any{
+		"latency", latency,
+		"upstream_calls", r.upstreamCalls(),
+		"outcome", r.outcome,
+	}
+
+	switch {
+	case r.err != nil:
+		r.logger.Error("command failed", append(attrs, "error", r.err)...)
+	case r.outcome == outcomeUnknown:
+		r.logger.Debug("unknown command", attrs...)
+	default:
+		r.logger.Info("command", attrs...)
+	}
+}
@@ -1,21 +1,33 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/famendola1/fantasy-discord-bot/providers"
+	"github.com/famendola1/fantasy-discord-bot/scheduler"
+	"github.com/famendola1/fantasy-discord-bot/storage"
 )
 
-func usageError(comm string) string {
-	return fmt.Sprintf("Error: invald !%s usage. See !help for usage.", comm)
+const (
+	// defaultTimeout is how long a command may take if no timeout is configured.
+	defaultTimeout = 30 * time.Second
+
+	// typingInterval is how often the typing indicator is refreshed.
+	typingInterval = 8 * time.Second
+)
+
+func usageError(prefix, comm string) string {
+	return fmt.Sprintf("Error: invald %s%s usage. See %shelp for usage.", prefix, comm, prefix)
 }
 
-func parseArgs(prefix, command string, ind int, sep string) []string {
-	args := strings.TrimPrefix(command, prefix)
+func parseArgs(args string, ind int, sep string) []string {
 	if ind == -1 {
 		return strings.Fields(args)
 	}
@@ -30,6 +42,10 @@ func parseArgs(prefix, command string, ind int, sep string) []string {
 		parsedArgs = append(parsedArgs, arg)
 	}
 
+	if ind > len(argsSplit) {
+		return parsedArgs
+	}
+
 	argsWithSep := strings.Join(argsSplit[ind:], " ")
 	if sep == "" {
 		return append(parsedArgs, argsWithSep)
@@ -37,177 +53,384 @@ func parseArgs(prefix, command string, ind int, sep string) []string {
 	return append(parsedArgs, strings.Split(argsWithSep, sep)...)
 }
 
+// isAdmin reports whether the author of the message can manage the server.
+func isAdmin(s *discordgo.Session, m *discordgo.MessageCreate) bool {
+	perms, err := s.UserChannelPermissions(m.Author.ID, m.ChannelID)
+	if err != nil {
+		return false
+	}
+	return perms&discordgo.PermissionManageServer != 0
+}
+
+// hasRole reports whether the author of the message has the given role.
+func hasRole(m *discordgo.MessageCreate, role string) bool {
+	if role == "" || m.Member == nil {
+		return false
+	}
+	for _, r := range m.Member.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// startTyping shows the typing indicator in the channel until the returned
+// function is called. Discord clears the indicator after 10 seconds, so it is
+// refreshed until then.
+func startTyping(s *discordgo.Session, channelID string) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(typingInterval)
+		defer ticker.Stop()
+		for {
+			s.ChannelTyping(channelID)
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
 // CreateMessageCreateHandler create a handler for the MessageCreate Discord event.
-func CreateMessageCreateHandler(p providers.MessageCreateProvider) func(s *discordgo.Session, m *discordgo.MessageCreate) {
-	return func(s *discordgo.Session, m *discordgo.MessageCreate) {
+// Each command uses the provider and config held by settings when it arrives.
+// Settings changed with admin commands are kept in store. Commands scheduled
+// with the schedule commands are run by sched and sent with dg. Provider calls
+// are cancelled when ctx is done.
+func CreateMessageCreateHandler(ctx context.Context, dg *discordgo.Session, settings *Settings, store *storage.Store, sched *scheduler.Scheduler) func(s *discordgo.Session, m *discordgo.MessageCreate) {
+	cooldowns := newCooldownTracker()
+	schedules := &commandSchedules{dg: dg, settings: settings, store: store, sched: sched}
+
+	// handle runs the command in the message. Scheduled commands run with the
+	// ctx of their job and skip the cooldown. They are checked against the
+	// permissions and channels of the member who scheduled them, without
+	// telling the channel when they are denied. The error of a scheduled
+	// command, or the reason it didn't run, is returned.
+	handle := func(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, scheduled bool) (runErr error) {
 		// Ignore all messages created by the bot itself
 		if m.Author.ID == s.State.User.ID {
+			return nil
+		}
+
+		cur := settings.load()
+		p, cfg := cur.provider, cur.config
+
+		prefix := guildPrefix(cfg, store, m.GuildID)
+		comm, rawArgs, ok := splitCommand(m.Content, prefix, s.State.User.ID)
+		if !ok {
+			return nil
+		}
+		if !listening(s, m, cfg) {
+			if scheduled {
+				return errors.New("the bot no longer listens in the channel")
+			}
+			return nil
+		}
+
+		ctx, calls := providers.WithUpstreamCounter(ctx)
+		rec := &commandRecord{
+			command: comm,
+			logger: slog.With(
+				"request_id", newRequestID(),
+				"guild", m.GuildID,
+				"channel", m.ChannelID,
+				"user", m.Author.ID,
+				"command", comm,
+				"args", rawArgs,
+				"scheduled", scheduled,
+			),
+			start:         time.Now(),
+			outcome:       outcomeOK,
+			upstreamCalls: calls.Load,
+		}
+		defer rec.log()
+		defer func() {
+			if !scheduled || rec.outcome == outcomeOK {
+				return
+			}
+			if rec.err != nil {
+				runErr = fmt.Errorf("%s: %w", rec.outcome, rec.err)
+			} else {
+				runErr = errors.New(rec.outcome)
+			}
+		}()
+
+		usage := func() {
+			rec.outcome = outcomeUsage
+			s.ChannelMessageSend(m.ChannelID, usageError(prefix, comm))
+		}
+
+		// Anyone can see the prefix, only changing it is restricted.
+		if !(comm == "prefix" && strings.TrimSpace(rawArgs) == "") && !allowed(s, m, cfg, comm) {
+			rec.outcome = outcomeDenied
+			if scheduled {
+				rec.err = errors.New("the member who scheduled it can no longer run it")
+				return
+			}
+			deny(s, m, cfg, prefix, comm)
+			return
+		}
+
+		if channels := commandChannels(s, m, cfg, comm); channels != nil {
+			rec.outcome = outcomeDenied
+			if scheduled {
+				rec.err = errors.New("it can no longer be run in the channel")
+				return
+			}
+			wrongChannel(s, m, prefix, comm, channels)
 			return
 		}
 
-		if strings.HasPrefix(m.Content, "!scoreboard") {
-			args := parseArgs("!scoreboard", m.Content, -1, "")
+		cooldown := !scheduled && !hasRole(m, cfg.ExemptRole)
+		if cooldown {
+			if wait := cooldowns.check(cfg.Cooldowns, comm, m.Author.ID, m.ChannelID); wait > 0 {
+				rec.outcome = outcomeCooldown
+				s.ChannelMessageSendReply(m.ChannelID,
+					fmt.Sprintf("%s%s is on cooldown, try again in %s.", prefix, comm, wait.Round(time.Second)),
+					m.Reference())
+				return
+			}
+		}
+
+		// startCooldown records the use of the command once its arguments are
+		// valid, so that a typo doesn't use up the cooldown. Commands that don't
+		// call respond record it when they are done.
+		startCooldown := func() {
+			if cooldown {
+				cooldowns.record(cfg.Cooldowns, comm, m.Author.ID, m.ChannelID)
+				cooldown = false
+			}
+		}
+		defer func() {
+			if rec.outcome != outcomeUsage && rec.outcome != outcomeUnknown {
+				startCooldown()
+			}
+		}()
+
+		ctx, cancel := context.WithTimeout(ctx, cur.timeout)
+		defer cancel()
+
+		// respond starts the cooldown of the command, shows the typing indicator
+		// while the provider works on it and then sends its output, or a
+		// description of its error, to the channel. Long output is split into
+		// several messages.
+		respond := func(call func() (string, error)) {
+			startCooldown()
+			stop := startTyping(s, m.ChannelID)
+			out, err := call()
+			stop()
+			if err != nil {
+				rec.outcome = outcomeError
+				rec.err = err
+				out = errorMessage(prefix, comm, err)
+			}
+			if _, err := SendMessage(s, m.ChannelID, out); err != nil && rec.err == nil {
+				rec.outcome = outcomeError
+				rec.err = err
+			}
+		}
+
+		switch comm {
+		case "scoreboard":
+			args := parseArgs(rawArgs, -1, "")
 			week := 0
 			var err error
 			if len(args) > 0 {
 				week, err = strconv.Atoi(args[0])
 				if err != nil {
-					s.ChannelMessageSend(m.ChannelID, usageError("scoreboard"))
+					usage()
 					return
 				}
 			}
-			s.ChannelMessageSend(m.ChannelID, p.Scoreboard(week))
-			return
-		}
+			respond(func() (string, error) { return p.Scoreboard(ctx, week) })
 
-		if m.Content == "!standings" {
-			s.ChannelMessageSend(m.ChannelID, p.Standings())
-			return
-		}
+		case "standings":
+			respond(func() (string, error) { return p.Standings(ctx) })
 
-		if strings.HasPrefix(m.Content, "!roster ") {
-			args := parseArgs("!roster", m.Content, 0, "")
-			if len(args) != 1 {
-				s.ChannelMessageSend(m.ChannelID, usageError("roster"))
+		case "roster":
+			args := parseArgs(rawArgs, 0, "")
+			if len(args) != 1 || args[0] == "" {
+				usage()
 				return
 			}
-			s.ChannelMessageSend(m.ChannelID, p.Roster(args[0]))
-			return
-		}
+			respond(func() (string, error) { return p.Roster(ctx, args[0]) })
 
-		if strings.HasPrefix(m.Content, "!stats ") {
-			args := parseArgs("!stats", m.Content, 1, "")
+		case "stats":
+			args := parseArgs(rawArgs, 1, "")
 			if len(args) < 2 {
-				s.ChannelMessageSend(m.ChannelID, usageError("stats"))
+				usage()
 				return
 			}
-			s.ChannelMessageSend(m.ChannelID, p.PlayerStats(args[0], args[1]))
-			return
-		}
+			respond(func() (string, error) { return p.PlayerStats(ctx, args[0], args[1]) })
 
-		if strings.HasPrefix(m.Content, "!compare ") {
-			args := parseArgs("!compare", m.Content, 1, "/")
+		case "compare":
+			args := parseArgs(rawArgs, 1, "/")
 			if len(args) != 3 {
-				s.ChannelMessageSend(m.ChannelID, usageError("compare"))
+				usage()
 				return
 			}
 
-			s.ChannelMessageSend(m.ChannelID, p.Compare(args[0], args[1], args[2]))
-			return
-		}
+			respond(func() (string, error) { return p.Compare(ctx, args[0], args[1], args[2]) })
 
-		if strings.HasPrefix(m.Content, "!analyze ") {
-			args := parseArgs("!analyze", m.Content, 1, ",")
+		case "analyze":
+			args := parseArgs(rawArgs, 1, ",")
 
 			if len(args) < 2 {
-				s.ChannelMessageSend(m.ChannelID, usageError("analyze"))
+				usage()
 				return
 			}
 
-			s.ChannelMessageSend(m.ChannelID, p.AnalyzeFreeAgents(args[0], args[1:]))
-			return
-		}
+			respond(func() (string, error) { return p.AnalyzeFreeAgents(ctx, args[0], args[1:]) })
+
+		case "vs":
+			args := parseArgs(rawArgs, -1, "")
+			if len(args) == 0 {
+				usage()
+				return
+			}
 
-		if strings.HasPrefix(m.Content, "!vs ") {
-			args := parseArgs("!vs", m.Content, -1, "")
 			if week, err := strconv.Atoi(args[0]); err == nil {
 				tm := strings.Join(args[1:], " ")
 				if tm == "" {
-					s.ChannelMessageSend(m.ChannelID, usageError("vs"))
+					usage()
 					return
 				}
-				s.ChannelMessageSend(m.ChannelID, p.VsLeague(tm, week))
-				return
-			}
-
-			if len(args) == 0 {
-				s.ChannelMessageSend(m.ChannelID, usageError("vs"))
+				respond(func() (string, error) { return p.VsLeague(ctx, tm, week) })
 				return
 			}
 
-			s.ChannelMessageSend(m.ChannelID, p.VsLeague(strings.Join(args, " "), 0))
-			return
-		}
+			respond(func() (string, error) { return p.VsLeague(ctx, strings.Join(args, " "), 0) })
 
-		if strings.HasPrefix(m.Content, "!schedule ") {
-			args := parseArgs("!schedule", m.Content, 0, "")
-			if len(args) == 0 {
-				s.ChannelMessageSend(m.ChannelID, usageError("schedule"))
+		case "schedule":
+			args := parseArgs(rawArgs, 0, "")
+			if len(args) == 0 || args[0] == "" {
+				usage()
 				return
 			}
 
-			s.ChannelMessageSend(m.ChannelID, p.Schedule(args[0]))
-			return
-		}
+			respond(func() (string, error) { return p.Schedule(ctx, args[0]) })
 
-		if strings.HasPrefix(m.Content, "!owner ") {
-			args := parseArgs("!owner", m.Content, 0, ",")
-			if len(args) == 0 {
-				s.ChannelMessageSend(m.ChannelID, usageError("owner"))
+		case "owner":
+			args := parseArgs(rawArgs, 0, ",")
+			if len(args) == 0 || args[0] == "" {
+				usage()
 				return
 			}
 
-			s.ChannelMessageSend(m.ChannelID, p.Owner(args))
-			return
-		}
+			respond(func() (string, error) { return p.Owner(ctx, args) })
 
-		if strings.HasPrefix(m.Content, "!leaders") {
-			args := parseArgs("!leaders", m.Content, -1, "")
+		case "leaders":
+			args := parseArgs(rawArgs, -1, "")
 			pst, _ := time.LoadLocation("America/Los_Angeles")
 			date := time.Now().In(pst).Format("2006-01-02")
 			if len(args) > 1 {
-				s.ChannelMessageSend(m.ChannelID, usageError("leaders"))
+				usage()
 				return
 			}
 
 			if len(args) == 1 {
 				date = args[0]
 			}
-			s.ChannelMessageSend(m.ChannelID, p.Leaders(date))
-			return
-		}
+			respond(func() (string, error) { return p.Leaders(ctx, date) })
 
-		if strings.HasPrefix(m.Content, "!h2h ") {
-			args := parseArgs("!h2h", m.Content, -1, "")
-			if week, err := strconv.Atoi(args[0]); err == nil {
-				tms := strings.Split(strings.Join(args[1:], " "), "/")
-				if len(tms) != 2 {
-					s.ChannelMessageSend(m.ChannelID, usageError("h2h"))
-					return
-				}
-				s.ChannelMessageSend(m.ChannelID, p.HeadToHead(week, tms[0], tms[1]))
+		case "h2h":
+			args := parseArgs(rawArgs, -1, "")
+			if len(args) == 0 {
+				usage()
 				return
 			}
 
-			if len(args) == 0 {
-				s.ChannelMessageSend(m.ChannelID, usageError("h2h"))
-				return
+			week, err := strconv.Atoi(args[0])
+			if err == nil {
+				args = args[1:]
 			}
 
 			tms := strings.Split(strings.Join(args, " "), "/")
-			s.ChannelMessageSend(m.ChannelID, p.HeadToHead(0, tms[0], tms[1]))
-			return
-		}
+			if len(tms) != 2 {
+				usage()
+				return
+			}
+			respond(func() (string, error) { return p.HeadToHead(ctx, week, tms[0], tms[1]) })
+
+		case "ranks":
+			args := parseArgs(rawArgs, -1, "")
+			if len(args) == 0 {
+				usage()
+				return
+			}
 
-		if strings.HasPrefix(m.Content, "!ranks ") {
-			args := parseArgs("!ranks", m.Content, -1, "")
 			if week, err := strconv.Atoi(args[0]); err == nil {
-				s.ChannelMessageSend(m.ChannelID, p.Ranks(week, args[1]))
+				if len(args) != 2 {
+					usage()
+					return
+				}
+				respond(func() (string, error) { return p.Ranks(ctx, week, args[1]) })
 				return
 			}
 
-			if len(args) == 0 {
-				s.ChannelMessageSend(m.ChannelID, usageError("ranks"))
+			respond(func() (string, error) { return p.Ranks(ctx, 0, args[0]) })
+
+		case "recap", "preview":
+			args := parseArgs(rawArgs, -1, "")
+			if len(args) > 1 {
+				usage()
 				return
 			}
 
-			s.ChannelMessageSend(m.ChannelID, p.Ranks(0, args[0]))
-			return
-		}
+			week := 0
+			if len(args) == 1 {
+				var err error
+				if week, err = strconv.Atoi(args[0]); err != nil {
+					usage()
+					return
+				}
+			}
+			if comm == "recap" {
+				respond(func() (string, error) { return p.Recap(ctx, week) })
+			} else {
+				respond(func() (string, error) { return p.Preview(ctx, week) })
+			}
 
-		if m.Content == "!help" {
-			s.ChannelMessageSendEmbed(m.ChannelID, p.Help())
-			return
+		case "flush":
+			s.ChannelMessageSend(m.ChannelID, p.FlushCache())
+
+		case "leagues":
+			respond(func() (string, error) { return p.Leagues(ctx) })
+
+		case "prefix":
+			prefixCommand(s, m, store, cfg, prefix, strings.TrimSpace(rawArgs), rec)
+
+		case "link", "unlink":
+			arg := strings.TrimSpace(rawArgs)
+			if comm == "link" && arg == "" {
+				usage()
+				return
+			}
+			respond(func() (string, error) { return linkCommand(ctx, s, m, p, store, cfg, prefix, comm, arg) })
+
+		case "schedule-add", "schedule-list", "schedule-remove":
+			scheduleCommand(s, m, schedules, cfg, prefix, comm, rawArgs, rec)
+
+		case "help":
+			s.ChannelMessageSendEmbed(m.ChannelID, withPrefix(p.Help(), prefix))
+
+		default:
+			rec.outcome = outcomeUnknown
 		}
+		return
+	}
+
+	schedules.run = func(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate) error {
+		return handle(ctx, s, m, true)
+	}
+	if err := schedules.reload(); err != nil {
+		slog.Error("error loading scheduled commands", "error", err)
+	}
+
+	return func(s *discordgo.Session, m *discordgo.MessageCreate) {
+		handle(ctx, s, m, false)
 	}
 }
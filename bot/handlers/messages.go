@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// MaxMessageLength is Discord's limit on the length of a message.
+	MaxMessageLength = 2000
+
+	// codeFence starts and ends a code block.
+	codeFence = "```"
+)
+
+// SplitMessage splits msg into messages that fit in Discord's limit. It splits
+// between paragraphs where it can, and otherwise between lines. A code block
+// that is split is closed at the end of one message and reopened in the next.
+func SplitMessage(msg string) []string {
+	if len(msg) <= MaxMessageLength {
+		return []string{msg}
+	}
+
+	sp := splitter{fresh: true}
+	for _, para := range strings.Split(msg, "\n\n") {
+		if !sp.fits("\n\n", para) {
+			sp.flush()
+		}
+		if sp.fits("\n\n", para) {
+			sp.add("\n\n", para)
+			continue
+		}
+
+		// The paragraph doesn't fit in a message of its own.
+		for i, line := range strings.Split(para, "\n") {
+			sep := "\n"
+			if i == 0 {
+				sep = "\n\n"
+			}
+			if !sp.fits(sep, line) {
+				sp.flush()
+			}
+			// Lines that don't fit in a message of their own are cut.
+			for !sp.fits(sep, line) {
+				n := sp.room()
+				for n > 0 && !utf8.RuneStart(line[n]) {
+					n--
+				}
+				sp.add(sep, line[:n])
+				sp.flush()
+				line = line[n:]
+			}
+			sp.add(sep, line)
+		}
+	}
+	sp.flush()
+	return sp.msgs
+}
+
+// splitter builds the messages of SplitMessage.
+type splitter struct {
+	msgs []string
+	cur  strings.Builder
+	// fresh is set if nothing was added to cur since the last message.
+	fresh bool
+	// open is set if cur ends inside a code block.
+	open bool
+}
+
+// room returns the length that can still be added to the current message,
+// keeping room to close a code block.
+func (sp *splitter) room() int {
+	return MaxMessageLength - len("\n"+codeFence) - sp.cur.Len()
+}
+
+// fits reports whether s, joined to the current message with sep, fits in it.
+func (sp *splitter) fits(sep, s string) bool {
+	if !sp.fresh {
+		s = sep + s
+	}
+	return len(s) <= sp.room()
+}
+
+// add adds s to the current message, joined with sep.
+func (sp *splitter) add(sep, s string) {
+	if !sp.fresh {
+		sp.cur.WriteString(sep)
+	}
+	sp.cur.WriteString(s)
+	sp.fresh = false
+	if strings.Count(s, codeFence)%2 == 1 {
+		sp.open = !sp.open
+	}
+}
+
+// flush ends the current message, if anything was added to it.
+func (sp *splitter) flush() {
+	if sp.fresh {
+		return
+	}
+	msg := sp.cur.String()
+	if sp.open {
+		msg += "\n" + codeFence
+	}
+	sp.msgs = append(sp.msgs, msg)
+	sp.cur.Reset()
+	sp.fresh = true
+	if sp.open {
+		sp.cur.WriteString(codeFence + "\n")
+	}
+}
+
+// SendMessage sends msg to the channel, split into as many messages as needed.
+// It returns the number of messages sent, which stops at the first error.
+func SendMessage(s *discordgo.Session, channelID, msg string) (int, error) {
+	msgs := SplitMessage(msg)
+	for i, m := range msgs {
+		if _, err := s.ChannelMessageSend(channelID, m); err != nil {
+			return i, err
+		}
+	}
+	return len(msgs), nil
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/famendola1/fantasy-discord-bot/providers"
+	"github.com/famendola1/fantasy-discord-bot/storage"
+)
+
+// ownersPrefix is the prefix of the storage keys of the members linked to
+// teams with the link command, by team key.
+const ownersPrefix = "owners/"
+
+// errTeamLinked is returned when linking a team that is linked to another
+// member.
+var errTeamLinked = errors.New("team is linked to another member")
+
+// teamOwner is the member linked to a team, as kept in storage.
+type teamOwner struct {
+	UserID string `json:"user_id"`
+	// Team is the name of the team when it was linked.
+	Team string `json:"team"`
+}
+
+// ownerKey is the storage key of the member linked to the team with the key.
+// Team keys don't change when teams are renamed.
+func ownerKey(teamKey string) string {
+	return ownersPrefix + teamKey
+}
+
+// TeamOwner returns the ID of the member linked to the team with the key, or
+// "" if no member is.
+func TeamOwner(store *storage.Store, teamKey string) (string, error) {
+	var owner teamOwner
+	if _, err := store.Get(ownerKey(teamKey), &owner); err != nil {
+		return "", err
+	}
+	return owner.UserID, nil
+}
+
+// linkCommand links the author of the message to the team named arg as its
+// owner, or unlinks them. Without a team, unlink removes all of the author's
+// links. A team linked to another member can only be unlinked by that member,
+// admins and the commissioner.
+func linkCommand(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, p providers.MessageCreateProvider, store *storage.Store, cfg Config, prefix, comm, arg string) (string, error) {
+	fp, ok := p.(providers.FeedProvider)
+	if !ok {
+		return "Error: the provider doesn't support linking teams.", nil
+	}
+
+	if comm == "unlink" && arg == "" {
+		var unlinked []string
+		for _, key := range store.Keys(ownersPrefix) {
+			var owner teamOwner
+			if _, err := store.Get(key, &owner); err != nil {
+				return "", err
+			}
+			if owner.UserID != m.Author.ID {
+				continue
+			}
+			if _, err := store.Delete(key); err != nil {
+				return "", err
+			}
+			unlinked = append(unlinked, "**"+owner.Team+"**")
+		}
+		if len(unlinked) == 0 {
+			return fmt.Sprintf("You aren't linked to a team. Link yours with `%slink <team>`.", prefix), nil
+		}
+		return fmt.Sprintf("Unlinked you from %s.", strings.Join(unlinked, ", ")), nil
+	}
+
+	team, err := fp.FindTeam(ctx, arg)
+	if err != nil {
+		return "", err
+	}
+	key := ownerKey(team.Key)
+
+	if comm == "unlink" {
+		var owner teamOwner
+		if _, err := store.Get(key, &owner); err != nil {
+			return "", err
+		}
+		switch {
+		case owner.UserID == "":
+			return fmt.Sprintf("**%s** isn't linked to anyone.", team.Name), nil
+		case owner.UserID != m.Author.ID && !isAdmin(s, m) && !hasRole(m, cfg.GuildCommissionerRole(m.GuildID)):
+			return fmt.Sprintf("**%s** can only be unlinked by the member linked to it, admins and the commissioner.", team.Name), nil
+		}
+		if _, err := store.Delete(key); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Unlinked **%s**.", team.Name), nil
+	}
+
+	var owner teamOwner
+	err = store.Update(key, &owner, func() error {
+		if owner.UserID != "" && owner.UserID != m.Author.ID {
+			return errTeamLinked
+		}
+		owner = teamOwner{UserID: m.Author.ID, Team: team.Name}
+		return nil
+	})
+	if errors.Is(err, errTeamLinked) {
+		return fmt.Sprintf("**%s** is already linked to another member. They can unlink it with `%sunlink`, or an admin or the commissioner with `%sunlink %s`.",
+			team.Name, prefix, prefix, team.Name), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Linked you to **%s**. Unlink it with `%sunlink`.", team.Name, prefix), nil
+}
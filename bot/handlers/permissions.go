@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Permission restricts who can run a command. Members with the Manage Server
+// permission can always run every command.
+type Permission struct {
+	// Everyone allows every member to run the command, e.g. to lift one of
+	// the default restrictions.
+	Everyone bool `json:"everyone,omitempty"`
+	// Roles are the IDs of the roles whose members can run the command.
+	Roles []string `json:"roles,omitempty"`
+	// Commissioner allows members with the commissioner role to run the
+	// command.
+	Commissioner bool `json:"commissioner,omitempty"`
+}
+
+// defaultPermissions restricts the admin commands to admins and the
+// commissioner unless configured otherwise.
+var defaultPermissions = map[string]Permission{
+	"flush":   {Commissioner: true},
+	"leagues": {Commissioner: true},
+	"prefix":  {Commissioner: true},
+
+	"schedule-add":    {Commissioner: true},
+	"schedule-list":   {Commissioner: true},
+	"schedule-remove": {Commissioner: true},
+}
+
+// permission returns the permission of the command in the guild, and whether
+// the command is restricted at all. A guild's permissions take precedence over
+// the handler's, which take precedence over the defaults.
+func (c Config) permission(guildID, comm string) (Permission, bool) {
+	if g, ok := c.Guilds[guildID]; ok {
+		if perm, ok := g.Permissions[comm]; ok {
+			return perm, !perm.Everyone
+		}
+	}
+	if perm, ok := c.Permissions[comm]; ok {
+		return perm, !perm.Everyone
+	}
+	perm, ok := defaultPermissions[comm]
+	return perm, ok
+}
+
+// GuildCommissionerRole returns the ID of the commissioner role in the guild.
+func (c Config) GuildCommissionerRole(guildID string) string {
+	if g, ok := c.Guilds[guildID]; ok && g.CommissionerRole != "" {
+		return g.CommissionerRole
+	}
+	return c.CommissionerRole
+}
+
+// allowed reports whether the author of the message can run the command.
+func allowed(s *discordgo.Session, m *discordgo.MessageCreate, cfg Config, comm string) bool {
+	perm, restricted := cfg.permission(m.GuildID, comm)
+	if !restricted {
+		return true
+	}
+
+	for _, role := range perm.Roles {
+		if hasRole(m, role) {
+			return true
+		}
+	}
+	if perm.Commissioner && hasRole(m, cfg.GuildCommissionerRole(m.GuildID)) {
+		return true
+	}
+	return isAdmin(s, m)
+}
+
+// deny tells the author of the message who can run the command. Roles are
+// mentioned without notifying their members.
+func deny(s *discordgo.Session, m *discordgo.MessageCreate, cfg Config, prefix, comm string) {
+	perm, _ := cfg.permission(m.GuildID, comm)
+
+	var who []string
+	for _, role := range perm.Roles {
+		who = append(who, "<@&"+role+">")
+	}
+	if role := cfg.GuildCommissionerRole(m.GuildID); perm.Commissioner && role != "" {
+		who = append(who, "the commissioner (<@&"+role+">)")
+	}
+	who = append(who, "members with the Manage Server permission")
+
+	s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+		Content: fmt.Sprintf("Error: you don't have permission to run %s%s. It can only be run by %s.",
+			prefix, comm, joinOr(who)),
+		Reference:       m.Reference(),
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+}
+
+// joinOr joins the items into a list such as "a, b or c".
+func joinOr(items []string) string {
+	if len(items) == 1 {
+		return items[0]
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " or " + items[len(items)-1]
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/famendola1/fantasy-discord-bot/storage"
+)
+
+const (
+	// defaultPrefix is the prefix of commands if none is configured.
+	defaultPrefix = "!"
+
+	// maxPrefixLength is the maximum length of a prefix, in bytes.
+	maxPrefixLength = 8
+)
+
+// ValidatePrefix returns an error if prefix can't be used as a command prefix.
+func ValidatePrefix(prefix string) error {
+	switch {
+	case prefix == "":
+		return errors.New("prefix must not be empty")
+	case len(prefix) > maxPrefixLength:
+		return fmt.Errorf("prefix must be at most %d characters", maxPrefixLength)
+	case strings.IndexFunc(prefix, unicode.IsSpace) >= 0:
+		return errors.New("prefix must not contain spaces")
+	}
+	return nil
+}
+
+// prefixKey is the storage key of the prefix set for a guild with the prefix
+// command.
+func prefixKey(guildID string) string {
+	return "guilds/" + guildID + "/prefix"
+}
+
+// guildPrefix returns the prefix of commands in the guild. A prefix set with
+// the prefix command takes precedence over the guild's config, which takes
+// precedence over the handler's.
+func guildPrefix(cfg Config, store *storage.Store, guildID string) string {
+	if guildID != "" {
+		var prefix string
+		if ok, err := store.Get(prefixKey(guildID), &prefix); err != nil {
+			slog.Warn("error reading guild prefix", "guild", guildID, "error", err)
+		} else if ok {
+			return prefix
+		}
+
+		if g, ok := cfg.Guilds[guildID]; ok && g.Prefix != "" {
+			return g.Prefix
+		}
+	}
+
+	if cfg.Prefix != "" {
+		return cfg.Prefix
+	}
+	return defaultPrefix
+}
+
+// splitCommand splits a message into the command name and its arguments. A
+// command starts with the prefix or with a mention of the bot, optionally
+// followed by the prefix. ok is false if the message isn't a command.
+func splitCommand(content, prefix, botID string) (name, args string, ok bool) {
+	if rest, found := trimMention(content, botID); found {
+		content = strings.TrimPrefix(strings.TrimLeftFunc(rest, unicode.IsSpace), prefix)
+	} else if rest, found := strings.CutPrefix(content, prefix); found {
+		content = rest
+	} else {
+		return "", "", false
+	}
+
+	name, args, _ = strings.Cut(content, " ")
+	if name == "" {
+		return "", "", false
+	}
+	return name, args, true
+}
+
+// trimMention removes a leading mention of the user from content.
+func trimMention(content, userID string) (string, bool) {
+	if userID == "" {
+		return content, false
+	}
+	for _, mention := range []string{"<@" + userID + ">", "<@!" + userID + ">"} {
+		if rest, ok := strings.CutPrefix(content, mention); ok {
+			return rest, true
+		}
+	}
+	return content, false
+}
+
+// withPrefix returns a copy of the help embed with the "!" prefix of commands
+// replaced by prefix.
+func withPrefix(embed *discordgo.MessageEmbed, prefix string) *discordgo.MessageEmbed {
+	if prefix == defaultPrefix {
+		return embed
+	}
+
+	e := *embed
+	e.Fields = make([]*discordgo.MessageEmbedField, len(embed.Fields))
+	for i, f := range embed.Fields {
+		field := *f
+		if rest, ok := strings.CutPrefix(field.Name, defaultPrefix); ok {
+			field.Name = prefix + rest
+		}
+		e.Fields[i] = &field
+	}
+	return &e
+}
+
+// prefixCommand shows the guild's prefix or changes it. "reset" removes the
+// prefix set with the command, going back to the configured one. The handler
+// checks the permission to change the prefix.
+func prefixCommand(s *discordgo.Session, m *discordgo.MessageCreate, store *storage.Store, cfg Config, prefix, arg string, rec *commandRecord) {
+	if m.GuildID == "" {
+		rec.outcome = outcomeUsage
+		s.ChannelMessageSend(m.ChannelID, "Error: the prefix can only be changed in a server.")
+		return
+	}
+	if arg == "" {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("The prefix is `%s`. Admins can change it with `%sprefix <prefix>`.", prefix, prefix))
+		return
+	}
+
+	if arg == "reset" {
+		if _, err := store.Delete(prefixKey(m.GuildID)); err != nil {
+			rec.outcome = outcomeError
+			rec.err = err
+			s.ChannelMessageSend(m.ChannelID, "Something went wrong resetting the prefix, try again later.")
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("The prefix is back to `%s`.", guildPrefix(cfg, store, m.GuildID)))
+		return
+	}
+
+	if err := ValidatePrefix(arg); err != nil {
+		rec.outcome = outcomeUsage
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %s.", err))
+		return
+	}
+	if err := store.Put(prefixKey(m.GuildID), arg); err != nil {
+		rec.outcome = outcomeError
+		rec.err = err
+		s.ChannelMessageSend(m.ChannelID, "Something went wrong changing the prefix, try again later.")
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("The prefix is now `%s`, e.g. `%shelp`.", arg, arg))
+}
@@ -0,0 +1,374 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/famendola1/fantasy-discord-bot/scheduler"
+	"github.com/famendola1/fantasy-discord-bot/storage"
+)
+
+const (
+	// scheduleGroup is the scheduler group of the scheduled commands.
+	scheduleGroup = "commands"
+
+	// schedulesPrefix is the prefix of the storage keys of scheduled commands.
+	schedulesPrefix = "schedules/"
+
+	// defaultScheduleTimezone is the timezone of schedules without one.
+	defaultScheduleTimezone = "America/Los_Angeles"
+
+	// maxSchedules is the maximum number of commands scheduled in a guild.
+	maxSchedules = 25
+)
+
+// errTooManySchedules is returned when scheduling a command in a guild that
+// already has maxSchedules.
+var errTooManySchedules = fmt.Errorf("at most %d commands can be scheduled in a server", maxSchedules)
+
+// unschedulable are the commands that can't be scheduled.
+var unschedulable = map[string]bool{
+	"link":            true,
+	"unlink":          true,
+	"prefix":          true,
+	"schedule-add":    true,
+	"schedule-list":   true,
+	"schedule-remove": true,
+}
+
+// scheduledCommand is a command run in a channel on a schedule.
+type scheduledCommand struct {
+	ID string `json:"id"`
+	// Schedule is the cron expression of the schedule, see scheduler.ParseCron.
+	Schedule string `json:"schedule"`
+	// Command is the command and its arguments, without the prefix.
+	Command string `json:"command"`
+	// CreatedBy is the ID of the user who scheduled the command.
+	CreatedBy string `json:"created_by"`
+}
+
+// cron returns the schedule of the command.
+func (c scheduledCommand) cron() (scheduler.Cron, error) {
+	loc, err := time.LoadLocation(defaultScheduleTimezone)
+	if err != nil {
+		return scheduler.Cron{}, err
+	}
+	return scheduler.ParseCron(c.Schedule, loc)
+}
+
+// schedulesKey is the storage key of the commands scheduled in a channel.
+func schedulesKey(guildID, channelID string) string {
+	return schedulesPrefix + guildID + "/" + channelID
+}
+
+// newScheduleID returns a short random ID for a scheduled command.
+func newScheduleID() string {
+	b := make([]byte, 3)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// commandSchedules keeps the scheduler's jobs in sync with the commands
+// scheduled in storage.
+type commandSchedules struct {
+	dg       *discordgo.Session
+	settings *Settings
+	store    *storage.Store
+	sched    *scheduler.Scheduler
+	// run runs a scheduled command as if it was sent in a message, and
+	// returns its error.
+	run func(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate) error
+
+	// mu serializes changes, so that the jobs reflect the latest one.
+	mu sync.Mutex
+}
+
+// guildSchedules returns the commands scheduled in the guild by channel ID.
+func (c *commandSchedules) guildSchedules(guildID string) (map[string][]scheduledCommand, error) {
+	prefix := schedulesKey(guildID, "")
+	schedules := make(map[string][]scheduledCommand)
+	for _, key := range c.store.Keys(prefix) {
+		var cmds []scheduledCommand
+		if _, err := c.store.Get(key, &cmds); err != nil {
+			return nil, err
+		}
+		if len(cmds) > 0 {
+			schedules[strings.TrimPrefix(key, prefix)] = cmds
+		}
+	}
+	return schedules, nil
+}
+
+// reload replaces the scheduler's jobs with the commands in storage.
+func (c *commandSchedules) reload() error {
+	var jobs []scheduler.Job
+	for _, key := range c.store.Keys(schedulesPrefix) {
+		guildID, channelID, ok := strings.Cut(strings.TrimPrefix(key, schedulesPrefix), "/")
+		if !ok {
+			continue
+		}
+		var cmds []scheduledCommand
+		if _, err := c.store.Get(key, &cmds); err != nil {
+			return err
+		}
+		for _, cmd := range cmds {
+			cron, err := cmd.cron()
+			if err != nil {
+				slog.Warn("skipping invalid scheduled command", "guild", guildID, "channel", channelID, "id", cmd.ID, "error", err)
+				continue
+			}
+			jobs = append(jobs, c.job(guildID, channelID, cmd, cron))
+		}
+	}
+	c.sched.Set(scheduleGroup, jobs)
+	return nil
+}
+
+// job returns the job running the scheduled command.
+func (c *commandSchedules) job(guildID, channelID string, cmd scheduledCommand, cron scheduler.Cron) scheduler.Job {
+	name, _, _ := strings.Cut(cmd.Command, " ")
+	return scheduler.Job{
+		Name:     "command:" + name,
+		ID:       "command:" + cmd.ID,
+		Schedule: cron,
+		Run: func(ctx context.Context) error {
+			// The command runs with the current roles of the member who
+			// scheduled it.
+			member, err := c.dg.State.Member(guildID, cmd.CreatedBy)
+			if err != nil {
+				member, err = c.dg.GuildMember(guildID, cmd.CreatedBy)
+			}
+			if err != nil {
+				return fmt.Errorf("looking up the member who scheduled %s: %w", cmd.ID, err)
+			}
+
+			prefix := guildPrefix(c.settings.load().config, c.store, guildID)
+			return c.run(ctx, c.dg, &discordgo.MessageCreate{Message: &discordgo.Message{
+				GuildID:   guildID,
+				ChannelID: channelID,
+				Author:    &discordgo.User{ID: cmd.CreatedBy},
+				Member:    member,
+				Content:   prefix + cmd.Command,
+			}})
+		},
+	}
+}
+
+// add schedules the command in the channel.
+func (c *commandSchedules) add(guildID, channelID string, cmd scheduledCommand) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	schedules, err := c.guildSchedules(guildID)
+	if err != nil {
+		return err
+	}
+	n := 0
+	for _, cmds := range schedules {
+		n += len(cmds)
+	}
+	if n >= maxSchedules {
+		return errTooManySchedules
+	}
+
+	var cmds []scheduledCommand
+	if err := c.store.Update(schedulesKey(guildID, channelID), &cmds, func() error {
+		cmds = append(cmds, cmd)
+		return nil
+	}); err != nil {
+		return err
+	}
+	return c.reload()
+}
+
+// remove removes the scheduled command with the ID from the guild. ok is false
+// if there is none.
+func (c *commandSchedules) remove(guildID, id string) (cmd scheduledCommand, ok bool, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	schedules, err := c.guildSchedules(guildID)
+	if err != nil {
+		return cmd, false, err
+	}
+	for channelID, cmds := range schedules {
+		for i := range cmds {
+			if cmds[i].ID != id {
+				continue
+			}
+			cmd = cmds[i]
+			cmds = append(cmds[:i], cmds[i+1:]...)
+
+			key := schedulesKey(guildID, channelID)
+			if len(cmds) == 0 {
+				_, err = c.store.Delete(key)
+			} else {
+				err = c.store.Put(key, cmds)
+			}
+			if err != nil {
+				return cmd, false, err
+			}
+			return cmd, true, c.reload()
+		}
+	}
+	return cmd, false, nil
+}
+
+// scheduleCommand adds, lists or removes the commands scheduled in the guild.
+// The handler checks the permission to run it.
+func scheduleCommand(s *discordgo.Session, m *discordgo.MessageCreate, schedules *commandSchedules, cfg Config, prefix, comm, arg string, rec *commandRecord) {
+	if m.GuildID == "" {
+		rec.outcome = outcomeUsage
+		s.ChannelMessageSend(m.ChannelID, "Error: commands can only be scheduled in a server.")
+		return
+	}
+
+	fail := func(err error) {
+		rec.outcome = outcomeError
+		rec.err = err
+		s.ChannelMessageSend(m.ChannelID, "Something went wrong with the scheduled commands, try again later.")
+	}
+
+	switch comm {
+	case "schedule-add":
+		cmd, err := parseSchedule(arg, prefix)
+		if err != nil {
+			rec.outcome = outcomeUsage
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %s. See %shelp for usage.", err, prefix))
+			return
+		}
+		cron, err := cmd.cron()
+		if err != nil {
+			rec.outcome = outcomeUsage
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %s.", err))
+			return
+		}
+
+		name, _, _ := strings.Cut(cmd.Command, " ")
+		if unschedulable[name] || !knownCommand(schedules.settings, name) {
+			rec.outcome = outcomeUsage
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %s%s can't be scheduled.", prefix, name))
+			return
+		}
+		// Scheduled commands are also checked when they run, but without
+		// telling the channel, so the member is told now instead.
+		if !allowed(s, m, cfg, name) {
+			rec.outcome = outcomeDenied
+			deny(s, m, cfg, prefix, name)
+			return
+		}
+		if channels := commandChannels(s, m, cfg, name); channels != nil {
+			rec.outcome = outcomeDenied
+			wrongChannel(s, m, prefix, name, channels)
+			return
+		}
+
+		cmd.ID = newScheduleID()
+		cmd.CreatedBy = m.Author.ID
+		if err := schedules.add(m.GuildID, m.ChannelID, cmd); errors.Is(err, errTooManySchedules) {
+			rec.outcome = outcomeUsage
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %s. Remove one with `%sschedule-remove`.", err, prefix))
+			return
+		} else if err != nil {
+			fail(err)
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Scheduled `%s%s` in this channel, next at <t:%d:f>. Remove it with `%sschedule-remove %s`.",
+			prefix, cmd.Command, cron.Next(time.Now()).Unix(), prefix, cmd.ID))
+
+	case "schedule-list":
+		all, err := schedules.guildSchedules(m.GuildID)
+		if err != nil {
+			fail(err)
+			return
+		}
+		if len(all) == 0 {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("No commands are scheduled. Schedule one with `%sschedule-add`.", prefix))
+			return
+		}
+
+		channelIDs := make([]string, 0, len(all))
+		for id := range all {
+			channelIDs = append(channelIDs, id)
+		}
+		sort.Strings(channelIDs)
+
+		var lines []string
+		for _, channelID := range channelIDs {
+			for _, cmd := range all[channelID] {
+				line := fmt.Sprintf("`%s` <#%s> `%s` `%s%s`", cmd.ID, channelID, cmd.Schedule, prefix, cmd.Command)
+				if cron, err := cmd.cron(); err == nil {
+					line += fmt.Sprintf(", next at <t:%d:f>", cron.Next(time.Now()).Unix())
+				}
+				lines = append(lines, line)
+			}
+		}
+		if _, err := SendMessage(s, m.ChannelID, strings.Join(lines, "\n")); err != nil {
+			rec.outcome = outcomeError
+			rec.err = err
+		}
+
+	case "schedule-remove":
+		id := strings.TrimSpace(arg)
+		if id == "" || strings.ContainsAny(id, " \t\n") {
+			rec.outcome = outcomeUsage
+			s.ChannelMessageSend(m.ChannelID, usageError(prefix, comm))
+			return
+		}
+		cmd, ok, err := schedules.remove(m.GuildID, id)
+		if err != nil {
+			fail(err)
+			return
+		}
+		if !ok {
+			rec.outcome = outcomeUsage
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: no command is scheduled with the ID `%s`. See `%sschedule-list`.", id, prefix))
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Removed the schedule of `%s%s`.", prefix, cmd.Command))
+	}
+}
+
+// parseSchedule parses the arguments of schedule-add: a cron expression,
+// optionally starting with TZ=<zone>, followed by the command to run, with or
+// without the prefix.
+func parseSchedule(arg, prefix string) (scheduledCommand, error) {
+	fields := strings.Fields(arg)
+	n := 0
+	if n < len(fields) && strings.HasPrefix(fields[n], "TZ=") {
+		n++
+	}
+	if n < len(fields) && strings.HasPrefix(fields[n], "@") {
+		n++
+	} else {
+		n += 5
+	}
+	if len(fields) <= n {
+		return scheduledCommand{}, fmt.Errorf("expected a schedule followed by a command, e.g. `0 21 * * sun %sstandings`", prefix)
+	}
+
+	return scheduledCommand{
+		Schedule: strings.Join(fields[:n], " "),
+		Command:  strings.TrimPrefix(strings.Join(fields[n:], " "), prefix),
+	}, nil
+}
+
+// knownCommand reports whether the provider's help lists the command.
+func knownCommand(settings *Settings, name string) bool {
+	for _, f := range settings.Provider().Help().Fields {
+		usage, _, _ := strings.Cut(f.Name, " ")
+		if strings.TrimPrefix(usage, defaultPrefix) == name {
+			return true
+		}
+	}
+	return false
+}
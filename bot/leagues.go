@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/famendola1/fantasy-discord-bot/providers"
+)
+
+// runLeagues lists every league of the configured Yahoo account, across all
+// games and seasons, so that admins can pick the one to bind the bot to. Only
+// the auth section of the config is required.
+func runLeagues(w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("leagues", flag.ExitOnError)
+	path := fs.String("cfg", *cfg, "Path to the JSON, YAML or TOML config file.")
+	fs.Parse(args)
+
+	conf, err := readConfig(*path)
+	if err != nil {
+		return err
+	}
+	if err := conf.validateAuth(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cliTimeout)
+	defer cancel()
+
+	leagues, err := providers.UserLeagues(ctx, conf.Auth.Client())
+	if err != nil {
+		return err
+	}
+	if len(leagues) == 0 {
+		fmt.Fprintln(w, "No leagues found for this Yahoo account.")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\tKEY\tLEAGUE_ID\tGAME\tSEASON\tNAME\tSCORING\tTEAMS")
+	marked := false
+	for _, l := range leagues {
+		marker := ""
+		if !marked && l.IsLeague(conf.Game, conf.LeagueID) {
+			marker = "*"
+			marked = true
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%d\n",
+			marker, l.Key, l.ID, l.Game, l.Season, l.Name, l.ScoringType, l.NumTeams)
+	}
+	tw.Flush()
+	if marked {
+		fmt.Fprintln(w, "\n* is the league in the config.")
+	}
+	return nil
+}
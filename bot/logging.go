@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type logConfig struct {
+	// Level is one of debug, info, warn or error. Defaults to info.
+	Level string `json:"level,omitempty"`
+	// Format is either text or json. Defaults to text.
+	Format string `json:"format,omitempty"`
+}
+
+// newLogger returns a logger that writes to stderr as configured.
+func newLogger(conf logConfig) (*slog.Logger, error) {
+	var level slog.Level
+	if conf.Level != "" {
+		if err := level.UnmarshalText([]byte(conf.Level)); err != nil {
+			return nil, fmt.Errorf("invalid log level %q", conf.Level)
+		}
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(conf.Format) {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(os.Stderr, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(os.Stderr, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q", conf.Format)
+	}
+}
+
+// discordLogger routes discordgo's logs to slog.
+func discordLogger(msgL, caller int, format string, a ...interface{}) {
+	level := slog.LevelDebug
+	switch msgL {
+	case discordgo.LogError:
+		level = slog.LevelError
+	case discordgo.LogWarning:
+		level = slog.LevelWarn
+	case discordgo.LogInformational:
+		level = slog.LevelInfo
+	}
+	slog.Log(context.Background(), level, fmt.Sprintf(format, a...), "source", "discordgo")
+}
+
+// fatal logs the error and exits. Joined errors are logged one per line.
+func fatal(msg string, err error) {
+	for _, e := range unwrapJoined(err) {
+		slog.Error(msg, "error", e)
+	}
+	os.Exit(1)
+}
@@ -1,76 +1,156 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"flag"
 	"fmt"
-	"io/ioutil"
-	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/famendola1/fantasy-discord-bot/bot/handlers"
+	"github.com/famendola1/fantasy-discord-bot/health"
+	"github.com/famendola1/fantasy-discord-bot/metrics"
 	"github.com/famendola1/fantasy-discord-bot/providers"
-	"github.com/famendola1/yauth"
+	"github.com/famendola1/fantasy-discord-bot/scheduler"
+	"github.com/famendola1/fantasy-discord-bot/storage"
 )
 
 var (
-	cfg = flag.String("cfg", "", "Path to the config file containing")
+	cfg         = flag.String("cfg", "", "Path to the JSON, YAML or TOML config file. Fields can also be set with FANTASY_BOT_* environment variables.")
+	checkConfig = flag.Bool("check-config", false, "Validate the config, authenticate with the provider and fetch the league, then exit.")
 )
 
-type config struct {
-	Auth         yauth.YAuth `json:"auth"`
-	Provider     string      `json:"provider"`
-	Game         string      `json:"game"`
-	LeagueID     int         `json:"league_id"`
-	DiscordToken string      `json:"discord_token"`
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %[1]s [flags]\n       %[1]s setup [-cfg path]\n       %[1]s leagues [-cfg path]\n\nFlags:\n", os.Args[0])
+	flag.PrintDefaults()
 }
 
 func main() {
+	flag.Usage = usage
 	flag.Parse()
 
-	if *cfg == "" {
-		fmt.Println("no config file specified.")
+	switch flag.Arg(0) {
+	case "setup":
+		if err := runSetup(flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, "setup failed:", err)
+			os.Exit(1)
+		}
+		return
+	case "leagues":
+		if err := runLeagues(os.Stdout, flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, "listing leagues failed:", err)
+			os.Exit(1)
+		}
 		return
 	}
 
-	content, err := ioutil.ReadFile(*cfg)
+	if *checkConfig {
+		if !runCheck(os.Stdout, *cfg) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	conf, err := loadConfig(*cfg)
 	if err != nil {
-		log.Fatal("Error when opening file: ", err)
+		fatal("invalid config", err)
 	}
 
-	var conf config
-	err = json.Unmarshal(content, &conf)
+	logger, err := newLogger(conf.Log)
 	if err != nil {
-		log.Fatal("Error during Unmarshal(): ", err)
+		fatal("error configuring logging", err)
 	}
+	slog.SetDefault(logger)
+	discordgo.Logger = discordLogger
 
-	// // Create a new Discord session using the provided bot token.
+	// Create a new Discord session using the provided bot token.
 	dg, err := discordgo.New("Bot " + conf.DiscordToken)
 	if err != nil {
-		fmt.Println("error creating Discord session,", err)
-		return
+		fatal("error creating Discord session", err)
+	}
+
+	// ctx is cancelled on shutdown to abort any commands still in flight.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store, err := storage.Open(conf.StoragePath)
+	if err != nil {
+		fatal("error opening storage", err)
 	}
+	if conf.StoragePath == "" {
+		slog.Warn("storage_path is not set, changes made with admin commands will be lost on restart")
+	}
+
+	p := providers.NewYahooProvider(&conf.Auth, conf.Game, conf.LeagueID)
+	settings := handlers.NewSettings(p, conf.Handler)
+	sched := scheduler.New()
+	dg.AddHandler(handlers.CreateMessageCreateHandler(ctx, dg, settings, store, sched))
+	checker := health.NewChecker(p)
+
+	sched.Set("posts", postJobs(conf.Posts, dg, settings, store))
+	sched.Set("feeds", feedJobs(conf.Feeds, dg, settings, store))
 
-	if conf.Provider == "yahoo" {
-		dg.AddHandler(handlers.CreateMessageCreateHandler(providers.NewYahooProvider(&conf.Auth, conf.Game, conf.LeagueID)))
+	r := &reloader{
+		path: *cfg,
+		conf: conf,
+		apply: func(old, conf *config) {
+			// Only replace the provider, and lose its cache, if the league
+			// or credentials changed.
+			if providerChanged(old, conf) {
+				p = providers.NewYahooProvider(&conf.Auth, conf.Game, conf.LeagueID)
+				checker.SetProvider(p)
+			}
+			settings.Store(p, conf.Handler)
+			sched.Set("posts", postJobs(conf.Posts, dg, settings, store))
+			sched.Set("feeds", feedJobs(conf.Feeds, dg, settings, store))
+
+			if logger, err := newLogger(conf.Log); err == nil {
+				slog.SetDefault(logger)
+			}
+			if conf.DiscordToken != old.DiscordToken || conf.HTTPAddr != old.HTTPAddr || conf.StoragePath != old.StoragePath {
+				slog.Warn("discord_token, http_addr and storage_path changes take effect after a restart")
+			}
+		},
 	}
+	go r.run(ctx)
+
+	dg.AddHandler(func(s *discordgo.Session, c *discordgo.Connect) {
+		metrics.GatewayConnected()
+		checker.GatewayConnected()
+	})
+	dg.AddHandler(func(s *discordgo.Session, d *discordgo.Disconnect) {
+		metrics.GatewayDisconnected()
+		checker.GatewayDisconnected()
+	})
 
-	dg.Identify.Intents = discordgo.IntentsGuildMessages
+	// The guilds intent keeps the channels and threads in the state, which is
+	// how threads are matched to their parent channel.
+	dg.Identify.Intents = discordgo.IntentsGuilds | discordgo.IntentsGuildMessages
+
+	if conf.HTTPAddr != "" {
+		srv := startHTTPServer(conf.HTTPAddr, checker)
+		defer stopHTTPServer(srv)
+	}
 
 	// Open a websocket connection to Discord and begin listening.
 	err = dg.Open()
 	if err != nil {
-		fmt.Println("error opening connection,", err)
-		return
+		fatal("error opening connection", err)
 	}
+	slog.Info("bot is running", "provider", conf.Provider, "game", conf.Game, "league_id", conf.LeagueID)
+
+	go sched.Run(ctx)
 
 	sc := make(chan os.Signal, 1)
 	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt, os.Kill)
-	<-sc
+	sig := <-sc
+	slog.Info("shutting down", "signal", sig.String())
 
-	// Cleanly close down the Discord session.
+	// Cancel outstanding requests and cleanly close down the Discord session.
+	cancel()
 	dg.Close()
 }
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/famendola1/fantasy-discord-bot/bot/handlers"
+	"github.com/famendola1/fantasy-discord-bot/providers"
+	"github.com/famendola1/fantasy-discord-bot/scheduler"
+	"github.com/famendola1/fantasy-discord-bot/storage"
+)
+
+// defaultPostTimezone is the timezone of posts without one, which is also the
+// timezone of the "yesterday" leaders.
+const defaultPostTimezone = "America/Los_Angeles"
+
+// postsConfig configures the posts made on a schedule.
+type postsConfig struct {
+	// Leaders posts the previous day's stat leaders every day.
+	Leaders *postConfig `json:"leaders,omitempty"`
+	// Recap posts a recap of the last completed week every week.
+	Recap *postConfig `json:"recap,omitempty"`
+	// Preview posts a preview of the matchups of the current week every week.
+	Preview *postConfig `json:"preview,omitempty"`
+}
+
+// postConfig configures when and where a post is made.
+type postConfig struct {
+	// Channel is the ID of the channel to post in.
+	Channel string `json:"channel"`
+	// Time is the time of day of the post, formatted as HH:MM.
+	Time string `json:"time"`
+	// Timezone is the IANA timezone of Time. Defaults to America/Los_Angeles.
+	Timezone string `json:"timezone,omitempty"`
+	// Day is the day of the week of weekly posts. Defaults to Monday.
+	Day string `json:"day,omitempty"`
+}
+
+// daily returns the schedule of a daily post.
+func (c *postConfig) daily() (scheduler.Daily, error) {
+	tz := c.Timezone
+	if tz == "" {
+		tz = defaultPostTimezone
+	}
+	return scheduler.ParseDaily(c.Time, tz)
+}
+
+// weekly returns the schedule of a weekly post.
+func (c *postConfig) weekly() (scheduler.Weekly, error) {
+	daily, err := c.daily()
+	if err != nil {
+		return scheduler.Weekly{}, err
+	}
+	if c.Day == "" {
+		return scheduler.Weekly{Weekday: time.Monday, Daily: daily}, nil
+	}
+	day, err := scheduler.ParseWeekday(c.Day)
+	if err != nil {
+		return scheduler.Weekly{}, err
+	}
+	return scheduler.Weekly{Weekday: day, Daily: daily}, nil
+}
+
+// validate returns an error listing every invalid field of the post.
+func (c *postConfig) validate(name string, weekly bool) error {
+	var errs []error
+	if c.Channel == "" {
+		errs = append(errs, fmt.Errorf("posts.%s.channel is required", name))
+	}
+
+	var err error
+	if weekly {
+		_, err = c.weekly()
+	} else {
+		_, err = c.daily()
+	}
+	if err != nil {
+		errs = append(errs, fmt.Errorf("posts.%s: %w", name, err))
+	}
+	return errors.Join(errs...)
+}
+
+// validate returns an error listing every invalid post.
+func (c *postsConfig) validate() error {
+	var errs []error
+	if c.Leaders != nil {
+		errs = append(errs, c.Leaders.validate("leaders", false))
+	}
+	if c.Recap != nil {
+		errs = append(errs, c.Recap.validate("recap", true))
+	}
+	if c.Preview != nil {
+		errs = append(errs, c.Preview.validate("preview", true))
+	}
+	return errors.Join(errs...)
+}
+
+// postJobs returns the jobs making the configured posts. They use the provider
+// held by settings when they run, so that they follow config reloads. The
+// config must be valid.
+func postJobs(conf postsConfig, dg *discordgo.Session, settings *handlers.Settings, store *storage.Store) []scheduler.Job {
+	var jobs []scheduler.Job
+	if c := conf.Leaders; c != nil {
+		daily, _ := c.daily()
+		jobs = append(jobs, scheduler.Job{
+			Name:     "leaders",
+			Schedule: daily,
+			Run: func(ctx context.Context) error {
+				out, err := settings.Provider().Leaders(ctx, "yesterday")
+				if errors.Is(err, providers.ErrNoGames) {
+					slog.Info("skipping leaders post, there were no games yesterday")
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				_, err = handlers.SendMessage(dg, c.Channel, out)
+				return err
+			},
+		})
+	}
+	if c := conf.Recap; c != nil {
+		weekly, _ := c.weekly()
+		jobs = append(jobs, scheduler.Job{
+			Name:     "recap",
+			Schedule: weekly,
+			Run: func(ctx context.Context) error {
+				out, err := settings.Provider().Recap(ctx, 0)
+				if errors.Is(err, providers.ErrNoGames) {
+					slog.Info("skipping recap post, no week has finished yet")
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				return postOnce(dg, store, c.Channel, "recap", out)
+			},
+		})
+	}
+	if c := conf.Preview; c != nil {
+		weekly, _ := c.weekly()
+		jobs = append(jobs, scheduler.Job{
+			Name:     "preview",
+			Schedule: weekly,
+			Run: func(ctx context.Context) error {
+				out, err := settings.Provider().Preview(ctx, 0)
+				if errors.Is(err, providers.ErrNoGames) {
+					slog.Info("skipping preview post, the season is over")
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				return postOnce(dg, store, c.Channel, "preview", out)
+			},
+		})
+	}
+	return jobs
+}
+
+// postOnce sends the post to the channel unless the previous post of its kind
+// there had the same first line, which identifies what the post is about, e.g.
+// the week of a recap. This keeps the last recap of a finished season from
+// being posted every week, and a preview from being posted twice if the post
+// is moved to a later day.
+func postOnce(dg *discordgo.Session, store *storage.Store, channelID, kind, content string) error {
+	key := "posts/" + kind + "/" + channelID
+	header, _, _ := strings.Cut(strings.TrimPrefix(content, "```\n"), "\n")
+
+	var last string
+	if _, err := store.Get(key, &last); err != nil {
+		return err
+	}
+	if last == header {
+		slog.Info("skipping post, it was already made", "post", kind, "channel", channelID, "header", header)
+		return nil
+	}
+
+	// A post split into several messages is recorded once any of them is
+	// sent, so that it isn't repeated if a later one fails.
+	n, err := handlers.SendMessage(dg, channelID, content)
+	if n > 0 {
+		if perr := store.Put(key, header); perr != nil {
+			return errors.Join(err, perr)
+		}
+	}
+	return err
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDelay is how long to wait for writes to the config file to settle
+// before reloading it, since editors often write a file in several steps.
+const reloadDelay = 500 * time.Millisecond
+
+// reloader reloads the config when its file changes or the process receives
+// SIGHUP. A config that fails to load or validate is logged and ignored, so
+// the bot keeps running with the last good config.
+type reloader struct {
+	path string
+	conf *config
+	// apply swaps in the new config. It is only called from run, so it
+	// doesn't need to be safe for concurrent use.
+	apply func(old, conf *config)
+}
+
+// run reloads the config until ctx is done.
+func (r *reloader) run(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var changed <-chan fsnotify.Event
+	var watchErrs <-chan error
+	if r.path != "" {
+		w, err := r.watch()
+		if err != nil {
+			slog.Warn("not watching config file, reload with SIGHUP instead", "path", r.path, "error", err)
+		} else {
+			defer w.Close()
+			changed, watchErrs = w.Events, w.Errors
+		}
+	}
+
+	// Changes are debounced with a timer that is only armed while waiting
+	// for writes to settle.
+	timer := time.NewTimer(0)
+	if !timer.Stop() {
+		<-timer.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-hup:
+			slog.Info("reloading config", "reason", "SIGHUP")
+			r.reload()
+		case ev := <-changed:
+			if filepath.Clean(ev.Name) != filepath.Clean(r.path) || !ev.Has(fsnotify.Write|fsnotify.Create|fsnotify.Rename) {
+				continue
+			}
+			timer.Reset(reloadDelay)
+		case <-timer.C:
+			slog.Info("reloading config", "reason", "file changed")
+			r.reload()
+		case err := <-watchErrs:
+			slog.Warn("error watching config file", "path", r.path, "error", err)
+		}
+	}
+}
+
+// watch returns a watcher for the directory of the config file. The directory
+// is watched rather than the file because many editors replace the file
+// instead of writing to it, which would end a watch on the file itself.
+func (r *reloader) watch() (*fsnotify.Watcher, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := w.Add(filepath.Dir(r.path)); err != nil {
+		w.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// reload loads the config and applies it if it is valid.
+func (r *reloader) reload() {
+	conf, err := loadConfig(r.path)
+	if err != nil {
+		for _, e := range unwrapJoined(err) {
+			slog.Error("invalid config, keeping the current one", "error", e)
+		}
+		return
+	}
+
+	r.apply(r.conf, conf)
+	r.conf = conf
+	slog.Info("config reloaded")
+}
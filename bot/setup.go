@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/famendola1/fantasy-discord-bot/providers"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/yahoo"
+)
+
+// defaultSetupPath is where setup writes the config if no path is given.
+const defaultSetupPath = "conf.json"
+
+// setupPrompter reads answers to setup's questions.
+type setupPrompter struct {
+	in  *bufio.Scanner
+	out io.Writer
+}
+
+// ask prints the question and returns the answer, or def if the answer is
+// empty.
+func (p *setupPrompter) ask(question, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", question)
+	}
+
+	if !p.in.Scan() {
+		if err := p.in.Err(); err != nil {
+			return "", err
+		}
+		return "", io.ErrUnexpectedEOF
+	}
+	if answer := strings.TrimSpace(p.in.Text()); answer != "" {
+		return answer, nil
+	}
+	return def, nil
+}
+
+// require asks the question until it gets a non-empty answer.
+func (p *setupPrompter) require(question string) (string, error) {
+	for {
+		answer, err := p.ask(question, "")
+		if err != nil || answer != "" {
+			return answer, err
+		}
+	}
+}
+
+// runSetup walks the user through authorizing the bot with Yahoo and picking
+// a league, and writes the resulting config file.
+func runSetup(args []string) error {
+	fs := flag.NewFlagSet("setup", flag.ExitOnError)
+	path := fs.String("cfg", defaultSetupPath, "Path of the config file to write. Its extension selects JSON, YAML or TOML.")
+	fs.Parse(args)
+
+	p := &setupPrompter{in: bufio.NewScanner(os.Stdin), out: os.Stdout}
+	ctx := context.Background()
+
+	if _, err := os.Stat(*path); err == nil {
+		answer, err := p.ask(fmt.Sprintf("%s already exists. Overwrite it? (y/N)", *path), "")
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(answer, "y") {
+			return errors.New("not overwriting " + *path)
+		}
+	}
+
+	conf := &config{Provider: "yahoo"}
+
+	fmt.Println("Create a Yahoo app at https://developer.yahoo.com/apps/create/ with the Fantasy Sports read permission and oob as its redirect URI.")
+	var err error
+	if conf.Auth.ClientID, err = p.require("Yahoo client ID"); err != nil {
+		return err
+	}
+	if conf.Auth.ClientSecret, err = p.require("Yahoo client secret"); err != nil {
+		return err
+	}
+
+	oauth := &oauth2.Config{
+		ClientID:     conf.Auth.ClientID,
+		ClientSecret: conf.Auth.ClientSecret,
+		Endpoint:     yahoo.Endpoint,
+		RedirectURL:  "oob",
+	}
+	fmt.Printf("\nOpen this URL, sign in and authorize the app:\n\n  %s\n\n", oauth.AuthCodeURL(""))
+	code, err := p.require("Code shown by Yahoo")
+	if err != nil {
+		return err
+	}
+	if conf.Auth.Token, err = oauth.Exchange(ctx, code); err != nil {
+		return fmt.Errorf("authorizing with Yahoo: %w", err)
+	}
+
+	if conf.Game, err = p.ask("\nGame code", "nba"); err != nil {
+		return err
+	}
+	leagues, err := providers.UserLeagues(ctx, oauth.Client(ctx, conf.Auth.Token), conf.Game)
+	if err != nil {
+		return fmt.Errorf("finding leagues: %w", err)
+	}
+	if len(leagues) == 0 {
+		return fmt.Errorf("no %s leagues found for this Yahoo account", conf.Game)
+	}
+
+	fmt.Println()
+	for i, l := range leagues {
+		fmt.Printf("  %d) %s (%s season, %d teams)\n", i+1, l.Name, l.Season, l.NumTeams)
+	}
+	for conf.LeagueID == 0 {
+		answer, err := p.ask("League", "1")
+		if err != nil {
+			return err
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(leagues) {
+			conf.LeagueID = leagues[n-1].ID
+		}
+	}
+
+	if conf.DiscordToken, err = p.require("\nDiscord bot token"); err != nil {
+		return err
+	}
+
+	if err := conf.validate(); err != nil {
+		return err
+	}
+	if err := writeConfigFile(*path, conf); err != nil {
+		return err
+	}
+	fmt.Printf("\nWrote %s. Start the bot with --cfg=%s\n", *path, *path)
+	return nil
+}
@@ -0,0 +1,140 @@
+// Package health serves liveness and readiness checks for the bot.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/famendola1/fantasy-discord-bot/providers"
+)
+
+// maxDisconnected is how long the Discord gateway may be disconnected before
+// the bot is considered wedged.
+const maxDisconnected = 5 * time.Minute
+
+// tokenTimeout is how long a readiness check waits for the provider's token,
+// which may be refreshed over the network.
+const tokenTimeout = 5 * time.Second
+
+// Checker tracks the state of the bot's connections.
+type Checker struct {
+	mu             sync.Mutex
+	provider       providers.HealthReporter
+	connected      bool
+	disconnectedAt time.Time
+}
+
+// NewChecker returns a Checker for a bot using the given provider. provider may
+// be nil if it can't report its health.
+func NewChecker(provider providers.HealthReporter) *Checker {
+	return &Checker{
+		provider:       provider,
+		disconnectedAt: time.Now(),
+	}
+}
+
+// SetProvider replaces the provider whose health is reported, e.g. after the
+// config is reloaded.
+func (c *Checker) SetProvider(provider providers.HealthReporter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.provider = provider
+}
+
+// GatewayConnected records that the Discord gateway connected.
+func (c *Checker) GatewayConnected() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.connected = true
+}
+
+// GatewayDisconnected records that the Discord gateway disconnected.
+func (c *Checker) GatewayDisconnected() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.connected {
+		c.connected = false
+		c.disconnectedAt = time.Now()
+	}
+}
+
+// report is the body of the health responses.
+type report struct {
+	OK                  bool       `json:"ok"`
+	GatewayConnected    bool       `json:"gateway_connected"`
+	DisconnectedSince   *time.Time `json:"disconnected_since,omitempty"`
+	LastProviderSuccess *time.Time `json:"last_provider_success,omitempty"`
+	TokenExpiry         *time.Time `json:"token_expiry,omitempty"`
+	Errors              []string   `json:"errors,omitempty"`
+}
+
+func (c *Checker) gateway(r *report) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	r.GatewayConnected = c.connected
+	if !c.connected {
+		since := c.disconnectedAt
+		r.DisconnectedSince = &since
+	}
+}
+
+func (c *Checker) providerHealth(ctx context.Context, r *report) {
+	c.mu.Lock()
+	provider := c.provider
+	c.mu.Unlock()
+	if provider == nil {
+		return
+	}
+
+	if last := provider.LastSuccess(); !last.IsZero() {
+		r.LastProviderSuccess = &last
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, tokenTimeout)
+	defer cancel()
+	expiry, err := provider.TokenExpiry(ctx)
+	if err != nil {
+		r.Errors = append(r.Errors, "provider token: "+err.Error())
+		return
+	}
+	r.TokenExpiry = &expiry
+}
+
+// Healthz reports whether the bot is alive. It fails if the Discord gateway has
+// been disconnected for too long, in which case the bot should be restarted.
+func (c *Checker) Healthz(w http.ResponseWriter, req *http.Request) {
+	var r report
+	c.gateway(&r)
+
+	r.OK = r.GatewayConnected || time.Since(*r.DisconnectedSince) < maxDisconnected
+	if !r.OK {
+		r.Errors = append(r.Errors, "gateway disconnected for more than "+maxDisconnected.String())
+	}
+	write(w, r)
+}
+
+// Readyz reports whether the bot can serve commands, which requires a
+// connected Discord gateway and a valid provider token.
+func (c *Checker) Readyz(w http.ResponseWriter, req *http.Request) {
+	var r report
+	c.gateway(&r)
+	c.providerHealth(req.Context(), &r)
+
+	if !r.GatewayConnected {
+		r.Errors = append(r.Errors, "gateway disconnected")
+	}
+	r.OK = len(r.Errors) == 0
+	write(w, r)
+}
+
+func write(w http.ResponseWriter, r report) {
+	w.Header().Set("Content-Type", "application/json")
+	if !r.OK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(r)
+}
@@ -0,0 +1,124 @@
+// Package metrics exports Prometheus metrics about the bot.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "fantasy_bot"
+
+var (
+	commands = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "commands_total",
+		Help:      "Number of commands handled, by command and outcome.",
+	}, []string{"command", "outcome"})
+
+	commandLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "command_duration_seconds",
+		Help:      "Time taken to handle commands, by command and outcome.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"command", "outcome"})
+
+	upstreamRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upstream_requests_total",
+		Help:      "Number of requests sent to the fantasy provider, by HTTP status code.",
+	}, []string{"code"})
+
+	upstreamLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upstream_request_duration_seconds",
+		Help:      "Time taken by requests to the fantasy provider, by HTTP status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"code"})
+
+	cacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_lookups_total",
+		Help:      "Number of provider cache lookups, by result (hit, miss or coalesced).",
+	}, []string{"result"})
+
+	jobs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "scheduled_jobs_total",
+		Help:      "Number of scheduled jobs run, by job and outcome.",
+	}, []string{"job", "outcome"})
+
+	gatewayConnected = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "gateway_connected",
+		Help:      "Whether the Discord gateway is connected.",
+	})
+
+	gatewayReconnects = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "gateway_reconnects_total",
+		Help:      "Number of times the Discord gateway reconnected.",
+	})
+
+	// connectedOnce is set after the first connection, so later connections
+	// are counted as reconnects.
+	connectedOnce atomic.Bool
+)
+
+// Handler returns an http.Handler that serves the metrics.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveCommand records a handled command.
+func ObserveCommand(command, outcome string, d time.Duration) {
+	commands.WithLabelValues(command, outcome).Inc()
+	commandLatency.WithLabelValues(command, outcome).Observe(d.Seconds())
+}
+
+// ObserveUpstream records a request sent to the fantasy provider. code is 0 if
+// the request failed without a response.
+func ObserveUpstream(code int, d time.Duration) {
+	label := "error"
+	if code != 0 {
+		label = strconv.Itoa(code)
+	}
+	upstreamRequests.WithLabelValues(label).Inc()
+	upstreamLatency.WithLabelValues(label).Observe(d.Seconds())
+}
+
+// Cache lookup results.
+const (
+	CacheHit       = "hit"
+	CacheMiss      = "miss"
+	CacheCoalesced = "coalesced"
+)
+
+// ObserveCacheLookup records a lookup in the provider cache.
+func ObserveCacheLookup(result string) {
+	cacheLookups.WithLabelValues(result).Inc()
+}
+
+// ObserveJob records a scheduled job that ran, or was skipped because its
+// previous run hadn't finished.
+func ObserveJob(job, outcome string) {
+	jobs.WithLabelValues(job, outcome).Inc()
+}
+
+// GatewayConnected records that the Discord gateway connected.
+func GatewayConnected() {
+	if connectedOnce.Swap(true) {
+		gatewayReconnects.Inc()
+	}
+	gatewayConnected.Set(1)
+}
+
+// GatewayDisconnected records that the Discord gateway disconnected.
+func GatewayDisconnected() {
+	gatewayConnected.Set(0)
+}
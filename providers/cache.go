@@ -0,0 +1,139 @@
+package providers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/famendola1/fantasy-discord-bot/metrics"
+)
+
+// Time-to-live for each kind of cached Yahoo resource. Data that can no longer
+// change is kept for hours, while live data is only kept long enough to absorb
+// a burst of identical commands.
+const (
+	ttlFinal      = 6 * time.Hour
+	ttlSettings   = 6 * time.Hour
+	ttlStandings  = 5 * time.Minute
+	ttlRosters    = 5 * time.Minute
+	ttlSchedule   = 30 * time.Minute
+	ttlPlayers    = 10 * time.Minute
+	ttlLeaders    = 10 * time.Minute
+	ttlTeamStats  = time.Minute
+	ttlScoreboard = 30 * time.Second
+)
+
+// sweepInterval is how often expired entries are removed from the cache, so
+// that entries for keys that are never requested again don't pile up.
+const sweepInterval = 10 * time.Minute
+
+type cacheEntry struct {
+	val     any
+	expires time.Time
+}
+
+// inflight is a request for a key that is currently being fetched. Callers
+// asking for the same key wait on it instead of sending their own request.
+type inflight struct {
+	done chan struct{}
+	val  any
+	err  error
+}
+
+// cache is a TTL cache of Yahoo responses that coalesces identical in-flight
+// requests.
+type cache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	calls   map[string]*inflight
+	// swept is when expired entries were last removed.
+	swept time.Time
+}
+
+func newCache() *cache {
+	return &cache{
+		entries: make(map[string]cacheEntry),
+		calls:   make(map[string]*inflight),
+		swept:   time.Now(),
+	}
+}
+
+// get returns the cached value for key if it hasn't expired. Otherwise fetch is
+// called and, if it succeeds, its result is cached for ttl. Concurrent calls
+// for the same key share a single fetch, but each caller stops waiting when its
+// own ctx is done.
+func (c *cache) get(ctx context.Context, key string, ttl time.Duration, fetch func() (any, error)) (any, error) {
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		if time.Now().Before(e.expires) {
+			c.mu.Unlock()
+			metrics.ObserveCacheLookup(metrics.CacheHit)
+			return e.val, nil
+		}
+		delete(c.entries, key)
+	}
+
+	if call, ok := c.calls[key]; ok {
+		c.mu.Unlock()
+		metrics.ObserveCacheLookup(metrics.CacheCoalesced)
+		select {
+		case <-call.done:
+			return call.val, call.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	call := &inflight{done: make(chan struct{})}
+	c.calls[key] = call
+	c.mu.Unlock()
+	metrics.ObserveCacheLookup(metrics.CacheMiss)
+
+	call.val, call.err = fetch()
+
+	c.mu.Lock()
+	delete(c.calls, key)
+	if call.err == nil {
+		c.entries[key] = cacheEntry{val: call.val, expires: time.Now().Add(ttl)}
+	}
+	c.sweep()
+	c.mu.Unlock()
+	close(call.done)
+
+	return call.val, call.err
+}
+
+// cached is a typed wrapper around cache.get.
+func cached[T any](ctx context.Context, c *cache, key string, ttl time.Duration, fetch func() (T, error)) (T, error) {
+	val, err := c.get(ctx, key, ttl, func() (any, error) { return fetch() })
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return val.(T), nil
+}
+
+// sweep removes the expired entries if they weren't removed in the last
+// sweepInterval. c.mu must be held.
+func (c *cache) sweep() {
+	now := time.Now()
+	if now.Sub(c.swept) < sweepInterval {
+		return
+	}
+	c.swept = now
+	for key, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// flush removes all cached entries. Requests that are in flight are unaffected.
+func (c *cache) flush() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := len(c.entries)
+	c.entries = make(map[string]cacheEntry)
+	return n
+}
@@ -0,0 +1,136 @@
+package providers
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+// Kinds of errors returned by providers. Use errors.Is to check for them.
+var (
+	ErrTeamNotFound   = errors.New("team not found")
+	ErrPlayerNotFound = errors.New("player not found")
+	ErrAmbiguousName  = errors.New("ambiguous name")
+	ErrInvalidWeek    = errors.New("invalid week")
+	ErrInvalidArgs    = errors.New("invalid arguments")
+	ErrNoGames        = errors.New("no games")
+	ErrAuthExpired    = errors.New("authorization expired")
+	ErrUnavailable    = errors.New("provider unavailable")
+)
+
+// Error is an error returned by a provider, classified by Kind.
+type Error struct {
+	// Kind is one of the Err* errors above.
+	Kind error
+	// Subject is the team, player, week, etc. that the error is about.
+	Subject string
+	// Suggestions are alternatives to Subject the user may have meant.
+	Suggestions []string
+	// Err is the underlying error, if any.
+	Err error
+}
+
+func (e *Error) Error() string {
+	msg := e.Kind.Error()
+	if e.Subject != "" {
+		msg = fmt.Sprintf("%s: %q", msg, e.Subject)
+	}
+	if e.Err != nil {
+		msg = fmt.Sprintf("%s: %v", msg, e.Err)
+	}
+	return msg
+}
+
+// Is reports whether target is the Kind of the error.
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// WeekRangeError is the underlying error of an ErrInvalidWeek error for a
+// week outside of the league's season.
+type WeekRangeError struct {
+	Start, End int
+}
+
+func (e *WeekRangeError) Error() string {
+	return fmt.Sprintf("weeks run from %d to %d", e.Start, e.End)
+}
+
+// statusRe matches the HTTP status that yfquery prefixes to Yahoo errors.
+var statusRe = regexp.MustCompile(`^(\d{3}) `)
+
+// classify wraps err in an Error describing its Kind, if it can be determined.
+// Errors that are already classified are returned as is.
+func classify(err error) error {
+	var perr *Error
+	if err == nil || errors.As(err, &perr) || errors.Is(err, ErrBusy) ||
+		errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	var rerr *oauth2.RetrieveError
+	if errors.As(err, &rerr) {
+		return &Error{Kind: ErrAuthExpired, Err: err}
+	}
+
+	var uerr *url.Error
+	var serr *xml.SyntaxError
+	if errors.As(err, &uerr) || errors.As(err, &serr) {
+		return &Error{Kind: ErrUnavailable, Err: err}
+	}
+
+	if m := statusRe.FindStringSubmatch(err.Error()); m != nil {
+		code, _ := strconv.Atoi(m[1])
+		switch {
+		case code == 401 || code == 403:
+			return &Error{Kind: ErrAuthExpired, Err: err}
+		case code >= 500:
+			return &Error{Kind: ErrUnavailable, Err: err}
+		case strings.Contains(strings.ToLower(err.Error()), "week"):
+			return &Error{Kind: ErrInvalidWeek, Err: err}
+		}
+	}
+
+	if strings.Contains(err.Error(), "must contain at least 3 letters") {
+		return &Error{Kind: ErrInvalidArgs, Err: err}
+	}
+
+	return err
+}
+
+// isNotFound reports whether err is yflib's error for a missing team or
+// player.
+func isNotFound(err error, what string) bool {
+	return strings.HasPrefix(err.Error(), what+" ") && strings.HasSuffix(err.Error(), " not found")
+}
+
+// itemError returns a short description of err for commands that report an
+// error per item instead of failing entirely.
+func itemError(err error) string {
+	err = classify(err)
+	var perr *Error
+	switch {
+	case errors.As(err, &perr) && perr.Kind == ErrInvalidArgs && perr.Err != nil:
+		return perr.Err.Error()
+	case errors.As(err, &perr):
+		return perr.Kind.Error()
+	case errors.Is(err, ErrBusy):
+		return "yahoo is busy"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timed out"
+	default:
+		return "unavailable"
+	}
+}
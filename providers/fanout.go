@@ -0,0 +1,40 @@
+package providers
+
+import "sync"
+
+// maxConcurrentRequests is the maximum number of Yahoo requests a single
+// command sends at once.
+const maxConcurrentRequests = 4
+
+// fanOut calls fn for each of the inputs using a bounded pool of workers. The
+// results and errors are returned in the same order as the inputs, so a
+// failure for one input doesn't affect the results of the others.
+func fanOut[In, Out any](inputs []In, fn func(In) (Out, error)) ([]Out, []error) {
+	results := make([]Out, len(inputs))
+	errs := make([]error, len(inputs))
+
+	workers := maxConcurrentRequests
+	if len(inputs) < workers {
+		workers = len(inputs)
+	}
+
+	idx := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range idx {
+				results[i], errs[i] = fn(inputs[i])
+			}
+		}()
+	}
+
+	for i := range inputs {
+		idx <- i
+	}
+	close(idx)
+	wg.Wait()
+
+	return results, errs
+}
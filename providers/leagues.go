@@ -0,0 +1,132 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/famendola1/yfquery"
+	"github.com/famendola1/yfquery/schema"
+)
+
+// LeagueInfo describes a league of the authenticated user.
+type LeagueInfo struct {
+	Key         string
+	ID          int
+	Name        string
+	Game        string
+	Season      string
+	ScoringType string
+	NumTeams    int
+}
+
+// userLeagues is the response to a query for the user's leagues by game.
+// yfquery's schema has no leagues under games, so it can't decode it.
+type userLeagues struct {
+	Games []struct {
+		Code    string          `xml:"code"`
+		Season  string          `xml:"season"`
+		Leagues []schema.League `xml:"leagues>league"`
+	} `xml:"users>user>games>game"`
+}
+
+// UserLeagues returns the leagues of the user that client is authorized for,
+// newest season first. If no games are given, the leagues of every game and
+// season are returned, otherwise only those of the given game codes, e.g. nba.
+func UserLeagues(ctx context.Context, client *http.Client, games ...string) ([]LeagueInfo, error) {
+	q := yfquery.Users().Games()
+	if len(games) > 0 {
+		q = q.Codes(games)
+	}
+
+	var resp userLeagues
+	if err := getXML(ctx, client, q.Leagues().ToString(), &resp); err != nil {
+		return nil, classify(err)
+	}
+
+	var leagues []LeagueInfo
+	for _, g := range resp.Games {
+		for _, l := range g.Leagues {
+			leagues = append(leagues, LeagueInfo{
+				Key:         l.LeagueKey,
+				ID:          l.LeagueID,
+				Name:        l.Name,
+				Game:        g.Code,
+				Season:      g.Season,
+				ScoringType: l.ScoringType,
+				NumTeams:    l.NumTeams,
+			})
+		}
+	}
+
+	sort.SliceStable(leagues, func(i, j int) bool {
+		if leagues[i].Season != leagues[j].Season {
+			return leagues[i].Season > leagues[j].Season
+		}
+		if leagues[i].Game != leagues[j].Game {
+			return leagues[i].Game < leagues[j].Game
+		}
+		return leagues[i].Name < leagues[j].Name
+	})
+	return leagues, nil
+}
+
+// maxLeaguesLength keeps the list of leagues within Discord's 2000 character
+// message limit.
+const maxLeaguesLength = 1900
+
+// IsLeague reports whether l is the league with the given ID in game, which is
+// either a game code such as nba or a game key such as 418.
+func (l LeagueInfo) IsLeague(game string, id int) bool {
+	gameKey, _, _ := strings.Cut(l.Key, ".")
+	return l.ID == id && (l.Game == game || gameKey == game)
+}
+
+// formatLeagues lists the leagues, marking the league with the given ID in game.
+// Since league IDs are only unique within a season, only the newest match is
+// marked.
+func formatLeagues(leagues []LeagueInfo, game string, id int) string {
+	var out strings.Builder
+
+	header := "Leagues"
+	out.WriteString("```\n")
+	out.WriteString(header)
+	out.WriteString("\n")
+	out.WriteString(strings.Repeat("-", len(header)))
+	out.WriteString("\n")
+	marked := false
+	for i, l := range leagues {
+		marker := " "
+		if !marked && l.IsLeague(game, id) {
+			marker = "*"
+			marked = true
+		}
+		line := fmt.Sprintf("%s %-12s %s %s %s, %s, %d teams\n",
+			marker, l.Key, l.Season, strings.ToUpper(l.Game), l.Name, l.ScoringType, l.NumTeams)
+		if out.Len()+len(line) > maxLeaguesLength {
+			fmt.Fprintf(&out, "...and %d more\n", len(leagues)-i)
+			break
+		}
+		out.WriteString(line)
+	}
+	out.WriteString("\n* is the league the bot is bound to.```")
+
+	return out.String()
+}
+
+// Leagues returns a formatted list of every league of the authenticated user,
+// across all games and seasons.
+func (y *Yahoo) Leagues(ctx context.Context) (string, error) {
+	leagues, err := cached(ctx, y.cache, "leagues", ttlSettings, func() ([]LeagueInfo, error) {
+		return UserLeagues(ctx, y.clientFor(ctx))
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(leagues) == 0 {
+		return "```No leagues found for this Yahoo account.```", nil
+	}
+	return formatLeagues(leagues, y.gameKey, y.leagueID), nil
+}
@@ -0,0 +1,175 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/famendola1/yflib"
+	"github.com/famendola1/yfquery"
+	"github.com/famendola1/yfquery/schema"
+)
+
+// percentStats are the stats that are rates rather than totals, so they
+// aren't averaged over weeks.
+var percentStats = map[int]bool{5: true, 8: true}
+
+// seasonTeamStats returns the season stats of every team in the league.
+func (y *Yahoo) seasonTeamStats(ctx context.Context) (*schema.Teams, error) {
+	return cached(ctx, y.cache, "teamstats:season", ttlTeamStats, func() (*schema.Teams, error) {
+		fc, err := yfquery.League().Key(y.leagueKey).Teams().Stats().CurrentSeason().Get(y.clientFor(ctx))
+		if err != nil {
+			return nil, err
+		}
+		return fc.League.Teams, nil
+	})
+}
+
+// weeklyAverage returns the team's season average per week of the stat, or
+// its season value if it is a percentage. ok is false if the team has no
+// value for the stat.
+func weeklyAverage(stats *schema.TeamStats, statID, weeks int) (float64, bool) {
+	val, err := strconv.ParseFloat(statValue(stats, statID), 64)
+	if err != nil {
+		return 0, false
+	}
+	if percentStats[statID] {
+		return val, true
+	}
+	return val / float64(weeks), true
+}
+
+// formatAverage formats a value returned by weeklyAverage.
+func formatAverage(statID int, val float64) string {
+	if percentStats[statID] {
+		return strings.TrimPrefix(fmt.Sprintf("%.3f", val), "0")
+	}
+	return fmt.Sprintf("%.1f", val)
+}
+
+// scheduledGames returns the number of games the team has scheduled in the
+// week of the scoreboard, or "?" if Yahoo didn't return it.
+func scheduledGames(tm *schema.Team) string {
+	if tm.TeamRemainingGames == nil || tm.TeamRemainingGames.Total == nil {
+		return "?"
+	}
+	t := tm.TeamRemainingGames.Total
+	return strconv.Itoa(t.RemainingGames + t.LiveGames + t.CompletedGames)
+}
+
+// formatMatchupPreview writes the preview of a matchup: the games each team
+// has scheduled, their season averages and which team is projected to win
+// each category. If weeks is 0, there are no averages to project with yet.
+func formatMatchupPreview(out *strings.Builder, a, b *schema.Team, season map[string]*schema.TeamStats, weeks int) {
+	header := fmt.Sprintf("%s vs %s", a.Name, b.Name)
+	out.WriteString(header)
+	out.WriteString("\n")
+	out.WriteString(strings.Repeat("-", len(header)))
+	out.WriteString("\n")
+	out.WriteString(fmt.Sprintf("%-4s %7s | %s\n", "GP:", scheduledGames(a), scheduledGames(b)))
+
+	if weeks == 0 {
+		out.WriteString("No season stats to project with yet.\n")
+		return
+	}
+
+	var w, l, t int
+	for _, stat := range orderedStats9CAT {
+		valA, okA := weeklyAverage(season[a.TeamKey], stat, weeks)
+		valB, okB := weeklyAverage(season[b.TeamKey], stat, weeks)
+		if !okA || !okB {
+			continue
+		}
+
+		winner := ""
+		switch {
+		case valA == valB:
+			t++
+		case (valA > valB) != (stat == 19):
+			w++
+			winner = "<"
+		default:
+			l++
+			winner = ">"
+		}
+		out.WriteString(fmt.Sprintf("%-4s %7s | %-7s %s\n",
+			yflib.StatIDToName[stat]+":", formatAverage(stat, valA), formatAverage(stat, valB), winner))
+	}
+
+	switch {
+	case w > l:
+		out.WriteString(fmt.Sprintf("Projected: %s %d-%d-%d\n", a.Name, w, l, t))
+	case l > w:
+		out.WriteString(fmt.Sprintf("Projected: %s %d-%d-%d\n", b.Name, l, w, t))
+	default:
+		out.WriteString(fmt.Sprintf("Projected: Tie %d-%d-%d\n", w, l, t))
+	}
+}
+
+func formatPreview(sb *schema.Scoreboard, season *schema.Teams, weeks int) string {
+	stats := make(map[string]*schema.TeamStats)
+	if season != nil {
+		for i := range season.Team {
+			stats[season.Team[i].TeamKey] = season.Team[i].TeamStats
+		}
+	}
+
+	var out strings.Builder
+	first := sb.Matchups.Matchup[0]
+	header := fmt.Sprintf("Week %d Preview (%s to %s)", first.Week, first.WeekStart, first.WeekEnd)
+	out.WriteString("```\n")
+	out.WriteString(header)
+	out.WriteString("\n")
+	if weeks > 0 {
+		out.WriteString(fmt.Sprintf("Season averages per week over %d weeks, FG%% and FT%% for the season.\n", weeks))
+	}
+
+	for _, m := range sb.Matchups.Matchup {
+		if m.Teams == nil || len(m.Teams.Team) != 2 {
+			continue
+		}
+		out.WriteString("\n")
+		formatMatchupPreview(&out, &m.Teams.Team[0], &m.Teams.Team[1], stats, weeks)
+	}
+	out.WriteString("```")
+	return out.String()
+}
+
+// Preview returns a preview of every matchup of the given week: the games each
+// team has scheduled, their season category averages side by side and the
+// projected winner of each category. If week is 0, the current week is used.
+func (y *Yahoo) Preview(ctx context.Context, week int) (string, error) {
+	l, err := y.currentLeague(ctx)
+	if err != nil {
+		return "", classify(err)
+	}
+	if week == 0 {
+		if l.IsFinished {
+			return "", &Error{Kind: ErrNoGames, Subject: "this week"}
+		}
+		week = l.CurrentWeek
+	} else if err := y.checkWeek(ctx, week); err != nil {
+		return "", err
+	}
+
+	sb, err := y.scoreboard(ctx, week)
+	if err != nil {
+		return "", classify(err)
+	}
+
+	// The stats are averaged over the completed weeks. The season stats also
+	// include the games of the current week played so far, which are few when
+	// the preview is posted at the start of the week.
+	weeks := l.CurrentWeek - l.StartWeek
+	if l.IsFinished {
+		weeks = l.EndWeek - l.StartWeek + 1
+	}
+	var season *schema.Teams
+	if weeks > 0 {
+		if season, err = y.seasonTeamStats(ctx); err != nil {
+			return "", classify(err)
+		}
+	}
+	return formatPreview(sb, season, weeks), nil
+}
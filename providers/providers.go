@@ -1,21 +1,45 @@
 package providers
 
-import "github.com/bwmarrin/discordgo"
+import (
+	"context"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
 
 // MessageCreateProvider is the interface for providers that are accessed on the
 // MessageCreate Discord event.
 type MessageCreateProvider interface {
-	Scoreboard(week int) string
-	Standings() string
-	Roster(teamName string) string
-	PlayerStats(statsType, playerName string) string
-	Compare(statsType, playerA, playerB string) string
-	AnalyzeFreeAgents(statsType string, stats []string) string
-	VsLeague(teamName string, week int) string
-	Schedule(teamName string) string
-	Owner(playerName []string) string
-	Leaders(date string) string
-	HeadToHead(week int, teamA, teamB string) string
-	Ranks(week int, stat string) string
+	Scoreboard(ctx context.Context, week int) (string, error)
+	Standings(ctx context.Context) (string, error)
+	Roster(ctx context.Context, teamName string) (string, error)
+	PlayerStats(ctx context.Context, statsType, playerName string) (string, error)
+	Compare(ctx context.Context, statsType, playerA, playerB string) (string, error)
+	AnalyzeFreeAgents(ctx context.Context, statsType string, stats []string) (string, error)
+	VsLeague(ctx context.Context, teamName string, week int) (string, error)
+	Schedule(ctx context.Context, teamName string) (string, error)
+	Owner(ctx context.Context, playerName []string) (string, error)
+	Leaders(ctx context.Context, date string) (string, error)
+	HeadToHead(ctx context.Context, week int, teamA, teamB string) (string, error)
+	Ranks(ctx context.Context, week int, stat string) (string, error)
+	Leagues(ctx context.Context) (string, error)
+	Recap(ctx context.Context, week int) (string, error)
+	Preview(ctx context.Context, week int) (string, error)
+	FlushCache() string
 	Help() *discordgo.MessageEmbed
 }
+
+// FeedProvider is the interface for providers whose league activity can be
+// polled and posted as it happens.
+type FeedProvider interface {
+	Transactions(ctx context.Context) ([]Transaction, error)
+	PendingTrades(ctx context.Context) ([]Transaction, error)
+	RosteredPlayers(ctx context.Context) ([]RosteredPlayer, error)
+	FindTeam(ctx context.Context, teamName string) (LeagueTeam, error)
+}
+
+// HealthReporter is the interface for providers that can report their health.
+type HealthReporter interface {
+	LastSuccess() time.Time
+	TokenExpiry(ctx context.Context) (time.Time, error)
+}
@@ -0,0 +1,36 @@
+package providers
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+)
+
+// yahooEndpoint is the base URL of the Yahoo Fantasy API.
+const yahooEndpoint = "https://fantasysports.yahooapis.com/fantasy/v2"
+
+// getXML sends a GET request for uri, as built by a yfquery query, and decodes
+// the response into out. The request is cancelled when ctx is done. It is used
+// for resources that yfquery's schema can't decode. Errors are formatted like
+// yfquery's so that classify understands them.
+func getXML(ctx context.Context, client *http.Client, uri string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, yahooEndpoint+uri, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var body struct {
+			Description string `xml:"description"`
+		}
+		xml.NewDecoder(resp.Body).Decode(&body)
+		return fmt.Errorf("%s: %s", resp.Status, body.Description)
+	}
+	return xml.NewDecoder(resp.Body).Decode(out)
+}
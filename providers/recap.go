@@ -0,0 +1,191 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/famendola1/yflib"
+	"github.com/famendola1/yfquery/schema"
+)
+
+// matchupResult is the category record of the first team of a matchup
+// against the second.
+type matchupResult struct {
+	teams              [2]*schema.Team
+	wins, losses, ties int
+}
+
+// margin is the difference in categories won by the teams.
+func (r matchupResult) margin() int {
+	if r.wins > r.losses {
+		return r.wins - r.losses
+	}
+	return r.losses - r.wins
+}
+
+func newMatchupResult(m schema.Matchup) matchupResult {
+	r := matchupResult{teams: [2]*schema.Team{&m.Teams.Team[0], &m.Teams.Team[1]}}
+	if m.StatWinners == nil {
+		return r
+	}
+	for _, s := range m.StatWinners.StatWinner {
+		switch {
+		case s.IsTied:
+			r.ties++
+		case s.WinnerTeamKey == r.teams[0].TeamKey:
+			r.wins++
+		case s.WinnerTeamKey == r.teams[1].TeamKey:
+			r.losses++
+		}
+	}
+	return r
+}
+
+// format describes the result with the winner first.
+func (r matchupResult) format(sep string) string {
+	if r.losses > r.wins {
+		return fmt.Sprintf("%s %s %s (%d-%d-%d)", r.teams[1].Name, sep, r.teams[0].Name, r.losses, r.wins, r.ties)
+	}
+	return fmt.Sprintf("%s %s %s (%d-%d-%d)", r.teams[0].Name, sep, r.teams[1].Name, r.wins, r.losses, r.ties)
+}
+
+// statValue returns the value of the stat in stats, or "" if it's missing.
+func statValue(stats *schema.TeamStats, statID int) string {
+	if stats == nil || stats.Stats == nil {
+		return ""
+	}
+	for _, s := range stats.Stats.Stat {
+		if s.StatID == statID {
+			return s.Value
+		}
+	}
+	return ""
+}
+
+// bestTeam returns the team with the best value of the stat among teams, and
+// that value. Turnovers are best when lowest.
+func bestTeam(teams []*schema.Team, statID int) (*schema.Team, string) {
+	var best *schema.Team
+	var bestVal float64
+	for _, tm := range teams {
+		raw := statValue(tm.TeamStats, statID)
+		val, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			continue
+		}
+		if best == nil || (statID == 19 && val < bestVal) || (statID != 19 && val > bestVal) {
+			best, bestVal = tm, val
+		}
+	}
+	if best == nil {
+		return nil, ""
+	}
+	return best, statValue(best.TeamStats, statID)
+}
+
+func formatRecap(sb *schema.Scoreboard, standings *schema.Standings) string {
+	var out strings.Builder
+
+	first := sb.Matchups.Matchup[0]
+	header := fmt.Sprintf("Week %d Recap (%s to %s)", first.Week, first.WeekStart, first.WeekEnd)
+	if first.Status != "postevent" {
+		header += " - In Progress"
+	}
+	out.WriteString("```\n")
+	out.WriteString(header)
+	out.WriteString("\n")
+	out.WriteString(strings.Repeat("-", len(header)))
+	out.WriteString("\n")
+
+	var results []matchupResult
+	var teams []*schema.Team
+	for _, m := range sb.Matchups.Matchup {
+		if m.Teams == nil || len(m.Teams.Team) != 2 {
+			continue
+		}
+		r := newMatchupResult(m)
+		results = append(results, r)
+		teams = append(teams, r.teams[:]...)
+		out.WriteString(r.format("def."))
+		out.WriteString("\n")
+	}
+
+	if len(results) > 0 {
+		blowout, closest := results[0], results[0]
+		for _, r := range results[1:] {
+			if r.margin() > blowout.margin() {
+				blowout = r
+			}
+			if r.margin() < closest.margin() {
+				closest = r
+			}
+		}
+		out.WriteString("\nBiggest blowout: ")
+		out.WriteString(blowout.format("def."))
+		out.WriteString("\nClosest matchup: ")
+		out.WriteString(closest.format("vs"))
+		out.WriteString("\n")
+	}
+
+	out.WriteString("\nTop Performances\n")
+	for _, stat := range orderedStats9CAT {
+		if tm, val := bestTeam(teams, stat); tm != nil {
+			out.WriteString(fmt.Sprintf("%-4s %s (%s)\n", yflib.StatIDToName[stat]+":", tm.Name, val))
+		}
+	}
+	out.WriteString("```")
+
+	if standings != nil {
+		out.WriteString("\n")
+		out.WriteString(formatYahooStandings(standings))
+	}
+	return out.String()
+}
+
+// completedWeek returns the last week of the season that is over.
+func (y *Yahoo) completedWeek(ctx context.Context) (int, error) {
+	l, err := y.currentLeague(ctx)
+	if err != nil {
+		return 0, classify(err)
+	}
+
+	week := l.CurrentWeek - 1
+	if l.IsFinished {
+		week = l.EndWeek
+	}
+	if week < l.StartWeek || week < 1 {
+		return 0, &Error{Kind: ErrNoGames, Subject: "last week"}
+	}
+	return week, nil
+}
+
+// Recap returns a summary of the given week: the result of every matchup, the
+// biggest blowout and closest matchup, the best team in each category and, for
+// the last completed week, the standings. The standings of earlier weeks
+// aren't available, so they are left out of their recaps. If week is 0, the
+// last completed week is used.
+func (y *Yahoo) Recap(ctx context.Context, week int) (string, error) {
+	completed, err := y.completedWeek(ctx)
+	if week == 0 {
+		if err != nil {
+			return "", err
+		}
+		week = completed
+	} else if err := y.checkWeek(ctx, week); err != nil {
+		return "", err
+	}
+
+	sb, err := y.scoreboard(ctx, week)
+	if err != nil {
+		return "", classify(err)
+	}
+
+	// The recap is still useful without the standings.
+	var standings *schema.Standings
+	if week == completed {
+		standings, _ = y.standings(ctx)
+	}
+	return formatRecap(sb, standings), nil
+}
@@ -0,0 +1,86 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/famendola1/yfquery"
+)
+
+// RosteredPlayer is a player on a team's roster and their injury status.
+type RosteredPlayer struct {
+	// Key uniquely identifies the player.
+	Key  string
+	Name string
+	// TeamKey uniquely identifies the player's team, which can be renamed.
+	TeamKey string
+	Team    string
+	// Position is the roster position the player is in, e.g. PG, BN or IL.
+	Position string
+	// Status is the player's short status, e.g. INJ, O, GTD or DTD, or "" if
+	// they have none.
+	Status string
+	// StatusFull is the long form of Status, e.g. Game Time Decision.
+	StatusFull string
+	// InjuryNote describes the injury, e.g. Ankle.
+	InjuryNote string
+}
+
+// RosteredPlayers returns the players on every team's roster. They aren't
+// cached, since they are polled for changes.
+func (y *Yahoo) RosteredPlayers(ctx context.Context) ([]RosteredPlayer, error) {
+	fc, err := yfquery.League().Key(y.leagueKey).Teams().Roster().Get(y.clientFor(ctx))
+	if err != nil {
+		return nil, classify(err)
+	}
+	if fc.League.Teams == nil {
+		return nil, nil
+	}
+
+	var players []RosteredPlayer
+	for _, tm := range fc.League.Teams.Team {
+		if tm.Roster == nil || tm.Roster.Players == nil {
+			continue
+		}
+		for _, p := range tm.Roster.Players.Player {
+			rp := RosteredPlayer{
+				Key:        p.PlayerKey,
+				TeamKey:    tm.TeamKey,
+				Team:       tm.Name,
+				Status:     p.Status,
+				StatusFull: p.StatusFull,
+				InjuryNote: p.InjuryNote,
+			}
+			if p.Name != nil {
+				rp.Name = p.Name.Full
+			}
+			if p.SelectedPosition != nil {
+				rp.Position = p.SelectedPosition.Position
+			}
+			players = append(players, rp)
+		}
+	}
+	return players, nil
+}
+
+// LeagueTeam identifies a team of the league.
+type LeagueTeam struct {
+	// Key uniquely identifies the team, which can be renamed.
+	Key  string
+	Name string
+}
+
+// FindTeam returns the team of the league with the name, ignoring case.
+func (y *Yahoo) FindTeam(ctx context.Context, teamName string) (LeagueTeam, error) {
+	standings, err := y.standings(ctx)
+	if err != nil {
+		return LeagueTeam{}, classify(err)
+	}
+	for _, tm := range standings.Teams.Team {
+		if strings.EqualFold(tm.Name, teamName) {
+			return LeagueTeam{Key: tm.TeamKey, Name: tm.Name}, nil
+		}
+	}
+	return LeagueTeam{}, y.teamError(ctx, fmt.Errorf("team %q not found", teamName), teamName)
+}
@@ -0,0 +1,215 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/famendola1/yfquery"
+	"github.com/famendola1/yfquery/schema"
+)
+
+// transactionsCount is the number of recent transactions fetched at a time.
+const transactionsCount = 25
+
+// Transaction is a change to the rosters of a league, such as an add, a drop,
+// a trade or a waiver claim.
+type Transaction struct {
+	// Key uniquely identifies the transaction.
+	Key string
+	// Type is add, drop, add/drop, trade, commish or pending_trade.
+	Type string
+	// Status is successful for completed transactions. Pending trades are
+	// proposed, or accepted once the other team accepts them.
+	Status string
+	Time   time.Time
+	// FAABBid is the winning bid of a waiver claim, or -1 if there was none.
+	FAABBid int
+	Moves   []Move
+}
+
+// Move is a player moved by a transaction.
+type Move struct {
+	Player string
+	// Type is add, drop, trade or pending_trade.
+	Type string
+	// From and To are the names of the teams the player moved between, or
+	// "" for the free agent pool and waivers.
+	From, To string
+	// Waivers is set if the player was claimed off waivers.
+	Waivers bool
+}
+
+// yahooTransactions is the response to a query for a league's transactions.
+// yfquery's schema expects the transactions in the wrong element, so it can't
+// decode it.
+type yahooTransactions struct {
+	Transactions []struct {
+		Key       string `xml:"transaction_key"`
+		Type      string `xml:"type"`
+		Status    string `xml:"status"`
+		Timestamp int64  `xml:"timestamp"`
+		FAABBid   *int   `xml:"faab_bid"`
+		Players   []struct {
+			Name string                 `xml:"name>full"`
+			Data schema.TransactionData `xml:"transaction_data"`
+		} `xml:"players>player"`
+	} `xml:"league>transactions>transaction"`
+}
+
+// Transactions returns the league's most recent transactions, newest first.
+// They aren't cached, since they are polled for changes.
+func (y *Yahoo) Transactions(ctx context.Context) ([]Transaction, error) {
+	var resp yahooTransactions
+	uri := yfquery.League().Key(y.leagueKey).Transactions().Count(transactionsCount).ToString()
+	if err := getXML(ctx, y.clientFor(ctx), uri, &resp); err != nil {
+		return nil, classify(err)
+	}
+	return resp.transactions(nil), nil
+}
+
+// PendingTrades returns the trades of the league that are proposed or
+// accepted but not processed yet. Yahoo only returns the pending trades of one
+// team at a time, so every team is queried, which requires an account that can
+// see them all, such as the commissioner's. An error for any team fails the
+// whole query, so that a trade is never missing by mistake. They aren't cached,
+// since they are polled for changes.
+func (y *Yahoo) PendingTrades(ctx context.Context) ([]Transaction, error) {
+	standings, err := y.standings(ctx)
+	if err != nil {
+		return nil, classify(err)
+	}
+	teams := standings.Teams.Team
+	names := make(map[string]string, len(teams))
+	for _, tm := range teams {
+		names[tm.TeamKey] = tm.Name
+	}
+
+	resps, errs := fanOut(teams, func(tm schema.Team) (yahooTransactions, error) {
+		var resp yahooTransactions
+		uri := yfquery.League().Key(y.leagueKey).Transactions().Types([]string{"pending_trade"}).TeamKey(tm.TeamKey).ToString()
+		return resp, getXML(ctx, y.clientFor(ctx), uri, &resp)
+	})
+	for _, err := range errs {
+		if err != nil {
+			return nil, classify(err)
+		}
+	}
+
+	// Each trade is returned for both of its teams.
+	var trades []Transaction
+	seen := make(map[string]bool)
+	for _, resp := range resps {
+		for _, tx := range resp.transactions(names) {
+			if !seen[tx.Key] {
+				seen[tx.Key] = true
+				trades = append(trades, tx)
+			}
+		}
+	}
+	return trades, nil
+}
+
+// transactions converts the response to Transactions. Team names missing from
+// the response are looked up by team key in names.
+func (resp yahooTransactions) transactions(names map[string]string) []Transaction {
+	teamName := func(name, key string) string {
+		if name == "" {
+			return names[key]
+		}
+		return name
+	}
+
+	txs := make([]Transaction, 0, len(resp.Transactions))
+	for _, t := range resp.Transactions {
+		tx := Transaction{
+			Key:     t.Key,
+			Type:    t.Type,
+			Status:  t.Status,
+			Time:    time.Unix(t.Timestamp, 0),
+			FAABBid: -1,
+		}
+		if t.FAABBid != nil {
+			tx.FAABBid = *t.FAABBid
+		}
+		for _, p := range t.Players {
+			tx.Moves = append(tx.Moves, Move{
+				Player:  p.Name,
+				Type:    p.Data.Type,
+				From:    teamName(p.Data.SourceTeamName, p.Data.SourceTeamKey),
+				To:      teamName(p.Data.DestinationTeamName, p.Data.DestinationTeamKey),
+				Waivers: p.Data.SourceType == "waivers",
+			})
+		}
+		txs = append(txs, tx)
+	}
+	return txs
+}
+
+// Format describes the transaction in a Discord message.
+func (t Transaction) Format() string {
+	switch t.Type {
+	case "trade":
+		return "**Trade:** " + t.Received()
+	case "pending_trade":
+		if t.Status == "accepted" {
+			return "**Trade accepted, pending review:** " + t.Received()
+		}
+		return "**Trade proposed:** " + t.Received()
+	}
+
+	// Describe the moves of each team in the order they appear.
+	var teams []string
+	moves := make(map[string][]string)
+	for _, m := range t.Moves {
+		team, desc := m.To, "added "+m.Player
+		switch {
+		case m.Type == "drop":
+			team, desc = m.From, "dropped "+m.Player
+		case m.Waivers && t.FAABBid >= 0:
+			desc = fmt.Sprintf("claimed %s off waivers for $%d FAAB", m.Player, t.FAABBid)
+		case m.Waivers:
+			desc = fmt.Sprintf("claimed %s off waivers", m.Player)
+		}
+		if _, ok := moves[team]; !ok {
+			teams = append(teams, team)
+		}
+		moves[team] = append(moves[team], desc)
+	}
+
+	lines := make([]string, len(teams))
+	for i, team := range teams {
+		lines[i] = fmt.Sprintf("**%s** %s.", team, joinAnd(moves[team]))
+	}
+	if t.Type == "commish" {
+		return "**Commissioner:** " + strings.Join(lines, " ")
+	}
+	return strings.Join(lines, " ")
+}
+
+// Received describes what each team of a trade receives.
+func (t Transaction) Received() string {
+	var teams []string
+	received := make(map[string][]string)
+	for _, m := range t.Moves {
+		if _, ok := received[m.To]; !ok {
+			teams = append(teams, m.To)
+		}
+		received[m.To] = append(received[m.To], m.Player)
+	}
+
+	parts := make([]string, len(teams))
+	for i, team := range teams {
+		parts[i] = fmt.Sprintf("**%s** receives %s.", team, joinAnd(received[team]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// joinAnd joins the items into a list such as "a, b and c".
+func joinAnd(items []string) string {
+	if len(items) < 2 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
@@ -0,0 +1,201 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/famendola1/fantasy-discord-bot/metrics"
+	"golang.org/x/time/rate"
+)
+
+// Limits for requests sent to Yahoo. Yahoo doesn't publish its quota, so these
+// are kept conservative.
+const (
+	requestsPerSecond = 2
+	requestBurst      = 10
+	maxRetries        = 3
+	baseBackoff       = time.Second
+	maxBackoff        = 30 * time.Second
+	maxQueueWait      = 10 * time.Second
+)
+
+// ErrBusy is returned when Yahoo keeps throttling requests or the bot's own
+// request budget is exhausted.
+var ErrBusy = errors.New("yahoo is busy")
+
+// rateLimitedTransport is an http.RoundTripper that limits the rate of requests
+// sent to Yahoo and retries throttled requests with exponential backoff.
+type rateLimitedTransport struct {
+	base    http.RoundTripper
+	limiter *rate.Limiter
+
+	// success is the time of the last successful response, in Unix nanoseconds.
+	success atomic.Int64
+}
+
+func newRateLimitedTransport(base http.RoundTripper) *rateLimitedTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &rateLimitedTransport{
+		base:    base,
+		limiter: rate.NewLimiter(requestsPerSecond, requestBurst),
+	}
+}
+
+// lastSuccess returns the time of the last successful response, or the zero
+// time if there hasn't been one.
+func (t *rateLimitedTransport) lastSuccess() time.Time {
+	ns := t.success.Load()
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
+// isThrottled reports whether the status code means Yahoo is throttling us.
+// Yahoo uses the non-standard 999 status for this in addition to 429.
+func isThrottled(code int) bool {
+	return code == 999 || code == http.StatusTooManyRequests || code == http.StatusServiceUnavailable
+}
+
+// retryAfter parses the Retry-After header of resp. It returns 0 if the header
+// is missing or malformed.
+func retryAfter(resp *http.Response) time.Duration {
+	h := resp.Header.Get("Retry-After")
+	if h == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(h); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(h); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// backoff returns how long to wait before the given retry attempt.
+func backoff(attempt int) time.Duration {
+	d := baseBackoff << attempt
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	// Add up to 50% jitter so concurrent requests don't retry in lockstep.
+	return d + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// wait blocks until the limiter allows another request. ErrBusy is returned if
+// the request would have to wait longer than maxQueueWait.
+func (t *rateLimitedTransport) wait(req *http.Request) error {
+	r := t.limiter.Reserve()
+	delay := r.Delay()
+	if delay > maxQueueWait {
+		r.Cancel()
+		return ErrBusy
+	}
+	return sleep(req, delay)
+}
+
+// sleep waits for d or until the request is cancelled.
+func sleep(req *http.Request, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-req.Context().Done():
+		return req.Context().Err()
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Requests with a body can only be retried if the body can be recreated.
+	retryable := req.Body == nil || req.GetBody != nil
+
+	for attempt := 0; ; attempt++ {
+		if err := t.wait(req); err != nil {
+			return nil, err
+		}
+
+		start := time.Now()
+		resp, err := t.base.RoundTrip(req)
+		if err != nil {
+			metrics.ObserveUpstream(0, time.Since(start))
+		} else {
+			metrics.ObserveUpstream(resp.StatusCode, time.Since(start))
+		}
+		if err != nil || !isThrottled(resp.StatusCode) {
+			if err == nil && resp.StatusCode < 300 {
+				t.success.Store(time.Now().UnixNano())
+			}
+			return resp, err
+		}
+
+		if !retryable || attempt == maxRetries {
+			resp.Body.Close()
+			return nil, ErrBusy
+		}
+
+		d := retryAfter(resp)
+		resp.Body.Close()
+		if d > maxBackoff {
+			return nil, ErrBusy
+		}
+		if d <= 0 {
+			d = backoff(attempt)
+		}
+
+		slog.Warn("yahoo throttled request, retrying",
+			"status", resp.StatusCode, "attempt", attempt+1, "wait", d, "url", req.URL.Path)
+		if err := sleep(req, d); err != nil {
+			return nil, err
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// contextTransport is an http.RoundTripper that binds every request to ctx.
+// yflib and yfquery don't accept a context, so this is how provider calls are
+// cancelled.
+type contextTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if calls, ok := t.ctx.Value(upstreamCallsKey{}).(*atomic.Int64); ok {
+		calls.Add(1)
+	}
+	return t.base.RoundTrip(req.WithContext(t.ctx))
+}
+
+type upstreamCallsKey struct{}
+
+// WithUpstreamCounter returns a context that counts the requests providers
+// send upstream on its behalf. Responses served from the cache aren't counted.
+func WithUpstreamCounter(ctx context.Context) (context.Context, *atomic.Int64) {
+	calls := &atomic.Int64{}
+	return context.WithValue(ctx, upstreamCallsKey{}, calls), calls
+}
@@ -1,6 +1,8 @@
 package providers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"sort"
@@ -13,12 +15,17 @@ import (
 	"github.com/famendola1/yflib"
 	"github.com/famendola1/yfquery"
 	"github.com/famendola1/yfquery/schema"
+	"golang.org/x/oauth2"
 )
 
 // Yahoo is a provider for Yahoo Fantasy Sports.
 type Yahoo struct {
 	client    *http.Client
+	transport *rateLimitedTransport
+	tokens    oauth2.TokenSource
+	cache     *cache
 	gameKey   string
+	leagueID  int
 	leagueKey string
 }
 
@@ -39,19 +46,160 @@ var (
 
 // NewYahooProvider returns a new Yahoo provider
 func NewYahooProvider(auth *yauth.YAuth, gameKey string, leagueID int) *Yahoo {
+	client := auth.Client()
+	transport := newRateLimitedTransport(client.Transport)
+	client.Transport = transport
+
+	var tokens oauth2.TokenSource
+	if t, ok := transport.base.(*oauth2.Transport); ok {
+		tokens = t.Source
+	}
+
 	return &Yahoo{
-		client:    auth.Client(),
+		client:    client,
+		transport: transport,
+		tokens:    tokens,
+		cache:     newCache(),
 		gameKey:   gameKey,
+		leagueID:  leagueID,
 		leagueKey: yflib.MakeLeagueKey(gameKey, leagueID),
 	}
 }
 
-func formatError(err error) string {
-	var out strings.Builder
-	out.WriteString("```\n")
-	out.WriteString(fmt.Sprintf("Error: %s", err))
-	out.WriteString("```")
-	return out.String()
+// clientFor returns a client whose requests are cancelled when ctx is done.
+func (y *Yahoo) clientFor(ctx context.Context) *http.Client {
+	return &http.Client{Transport: &contextTransport{ctx: ctx, base: y.client.Transport}}
+}
+
+// league returns the league's metadata.
+func (y *Yahoo) league(ctx context.Context) (*schema.League, error) {
+	return cached(ctx, y.cache, "league", ttlSettings, func() (*schema.League, error) {
+		fc, err := yfquery.League().Key(y.leagueKey).Get(y.clientFor(ctx))
+		if err != nil {
+			return nil, err
+		}
+		return fc.League, nil
+	})
+}
+
+// currentLeague returns the league's metadata like league, but kept only
+// briefly, for choosing the current week. The metadata of league can be hours
+// old when a new week starts.
+func (y *Yahoo) currentLeague(ctx context.Context) (*schema.League, error) {
+	return cached(ctx, y.cache, "league:current", ttlScoreboard, func() (*schema.League, error) {
+		fc, err := yfquery.League().Key(y.leagueKey).Get(y.clientFor(ctx))
+		if err != nil {
+			return nil, err
+		}
+		return fc.League, nil
+	})
+}
+
+// League returns the league's metadata.
+func (y *Yahoo) League(ctx context.Context) (*schema.League, error) {
+	l, err := y.league(ctx)
+	if err != nil {
+		return nil, classify(err)
+	}
+	return l, nil
+}
+
+// checkWeek returns an ErrInvalidWeek error if week isn't part of the league's
+// season. Week 0 stands for the current week and is always valid.
+func (y *Yahoo) checkWeek(ctx context.Context, week int) error {
+	if week == 0 {
+		return nil
+	}
+
+	invalid := &Error{Kind: ErrInvalidWeek, Subject: strconv.Itoa(week)}
+	if week < 0 {
+		return invalid
+	}
+
+	// If the league can't be fetched, let the command's own request fail instead.
+	l, err := y.league(ctx)
+	if err != nil || l.EndWeek == 0 {
+		return nil
+	}
+	if week < l.StartWeek || week > l.EndWeek {
+		invalid.Err = &WeekRangeError{Start: l.StartWeek, End: l.EndWeek}
+		return invalid
+	}
+	return nil
+}
+
+// teamError classifies err from looking up teamName. If the team wasn't
+// found, teams with similar names are suggested.
+func (y *Yahoo) teamError(ctx context.Context, err error, teamName string) error {
+	if !isNotFound(err, "team") {
+		return classify(err)
+	}
+
+	nerr := &Error{Kind: ErrTeamNotFound, Subject: teamName, Err: err}
+	standings, serr := y.standings(ctx)
+	if serr != nil {
+		return nerr
+	}
+
+	var names []string
+	for _, tm := range standings.Teams.Team {
+		names = append(names, tm.Name)
+	}
+	nerr.Suggestions = similarNames(teamName, names)
+	return nerr
+}
+
+// playerError classifies err from looking up one of playerNames. If a player
+// wasn't found, players with similar names are suggested, and if the name
+// matches several players, the error is ErrAmbiguousName.
+func (y *Yahoo) playerError(ctx context.Context, err error, playerNames ...string) error {
+	if !isNotFound(err, "player") {
+		return classify(err)
+	}
+
+	// Find which player wasn't found from the error, which contains the name.
+	name := playerNames[0]
+	for _, n := range playerNames {
+		if strings.Contains(err.Error(), strconv.Quote(n)) {
+			name = n
+		}
+	}
+
+	nerr := &Error{Kind: ErrPlayerNotFound, Subject: name, Err: err}
+	players, serr := cached(ctx, y.cache, "search:"+strings.ToLower(name), ttlPlayers, func() ([]*schema.Player, error) {
+		return yflib.SearchPlayers(y.clientFor(ctx), y.leagueKey, name)
+	})
+	if serr != nil {
+		return nerr
+	}
+
+	for _, p := range players {
+		nerr.Suggestions = append(nerr.Suggestions, p.Name.Full)
+	}
+	if len(nerr.Suggestions) > 1 {
+		nerr.Kind = ErrAmbiguousName
+	}
+	return nerr
+}
+
+// similarNames returns the names that contain name or share its first word,
+// ignoring case.
+func similarNames(name string, names []string) []string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	first := strings.Fields(name)
+
+	var similar []string
+	for _, n := range names {
+		lower := strings.ToLower(n)
+		if name != "" && (strings.Contains(lower, name) || strings.Contains(name, lower)) {
+			similar = append(similar, n)
+			continue
+		}
+		if len(first) > 0 && strings.HasPrefix(lower, first[0]) {
+			similar = append(similar, n)
+		}
+	}
+	return similar
 }
 
 func formatYahooScoreboard(sb *schema.Scoreboard) string {
@@ -81,20 +229,40 @@ func formatYahooScoreboard(sb *schema.Scoreboard) string {
 
 // Scoreboard returns a formatted string of all the Yahoo matchups for the given
 // week. If week is -1, then the current week is used.
-func (y *Yahoo) Scoreboard(week int) string {
-	var sb *schema.Scoreboard
-	var err error
+func (y *Yahoo) Scoreboard(ctx context.Context, week int) (string, error) {
+	if err := y.checkWeek(ctx, week); err != nil {
+		return "", err
+	}
 
-	if week == 0 {
-		sb, err = yflib.GetCurrentScoreboard(y.client, y.leagueKey)
-	} else {
-		sb, err = yflib.GetScoreboard(y.client, y.leagueKey, week)
+	sb, err := y.scoreboard(ctx, week)
+	if err != nil {
+		return "", classify(err)
 	}
+	return formatYahooScoreboard(sb), nil
+}
 
+// scoreboard returns the matchups of the given week, or of the current week if
+// week is 0. The scoreboards of weeks that are over are final, so they are
+// kept for longer.
+func (y *Yahoo) scoreboard(ctx context.Context, week int) (*schema.Scoreboard, error) {
+	ttl := ttlScoreboard
+	if l, err := y.league(ctx); err == nil && week > 0 && (week < l.CurrentWeek || l.IsFinished) {
+		ttl = ttlFinal
+	}
+
+	sb, err := cached(ctx, y.cache, fmt.Sprintf("scoreboard:%d", week), ttl, func() (*schema.Scoreboard, error) {
+		if week == 0 {
+			return yflib.GetCurrentScoreboard(y.clientFor(ctx), y.leagueKey)
+		}
+		return yflib.GetScoreboard(y.clientFor(ctx), y.leagueKey, week)
+	})
 	if err != nil {
-		return formatError(err)
+		return nil, err
 	}
-	return formatYahooScoreboard(sb)
+	if sb.Matchups == nil || len(sb.Matchups.Matchup) == 0 {
+		return nil, &Error{Kind: ErrInvalidWeek, Subject: strconv.Itoa(week)}
+	}
+	return sb, nil
 }
 
 func formatYahooStandings(standings *schema.Standings) string {
@@ -121,13 +289,20 @@ func formatYahooStandings(standings *schema.Standings) string {
 	return out.String()
 }
 
+// standings returns the league's standings.
+func (y *Yahoo) standings(ctx context.Context) (*schema.Standings, error) {
+	return cached(ctx, y.cache, "standings", ttlStandings, func() (*schema.Standings, error) {
+		return yflib.GetLeagueStandings(y.clientFor(ctx), y.leagueKey)
+	})
+}
+
 // Standings returns a formatted string containing the Yahoo league's standings.
-func (y *Yahoo) Standings() string {
-	standings, err := yflib.GetLeagueStandings(y.client, y.leagueKey)
+func (y *Yahoo) Standings(ctx context.Context) (string, error) {
+	standings, err := y.standings(ctx)
 	if err != nil {
-		return formatError(err)
+		return "", classify(err)
 	}
-	return formatYahooStandings(standings)
+	return formatYahooStandings(standings), nil
 }
 
 func formatYahooRoster(team *schema.Team) string {
@@ -161,12 +336,14 @@ func formatYahooRoster(team *schema.Team) string {
 }
 
 // Roster returns a formatted string containg the roster of a team.
-func (y *Yahoo) Roster(teamName string) string {
-	tm, err := yflib.GetTeamRoster(y.client, y.leagueKey, teamName)
+func (y *Yahoo) Roster(ctx context.Context, teamName string) (string, error) {
+	tm, err := cached(ctx, y.cache, "roster:"+strings.ToLower(teamName), ttlRosters, func() (*schema.Team, error) {
+		return yflib.GetTeamRoster(y.clientFor(ctx), y.leagueKey, teamName)
+	})
 	if err != nil {
-		return formatError(err)
+		return "", y.teamError(ctx, err, teamName)
 	}
-	return formatYahooRoster(tm)
+	return formatYahooRoster(tm), nil
 }
 
 func formatPlayerStats(player *schema.Player) string {
@@ -202,24 +379,31 @@ func convertStatsType(statsType string) (int, error) {
 		statsTypeNum = yflib.StatsTypeAverageLastMonth
 		break
 	default:
-		return yflib.StatsTypeUnknown, fmt.Errorf("invald stats type (%q) requested", statsType)
+		return yflib.StatsTypeUnknown, &Error{
+			Kind:    ErrInvalidArgs,
+			Subject: statsType,
+			Err:     errors.New("stats type must be one of season|week|month"),
+		}
 	}
 
 	return statsTypeNum, nil
 }
 
 // PlayerStats returns a formatted string containing the stats for a player.
-func (y *Yahoo) PlayerStats(statsType, playerName string) string {
+func (y *Yahoo) PlayerStats(ctx context.Context, statsType, playerName string) (string, error) {
 	statsTypeNum, err := convertStatsType(statsType)
 	if err != nil {
-		return formatError(err)
+		return "", err
 	}
 
-	p, err := yflib.GetPlayerStats(y.client, y.leagueKey, playerName, statsTypeNum)
+	key := fmt.Sprintf("stats:%d:%s", statsTypeNum, strings.ToLower(playerName))
+	p, err := cached(ctx, y.cache, key, ttlPlayers, func() (*schema.Player, error) {
+		return yflib.GetPlayerStats(y.clientFor(ctx), y.leagueKey, playerName, statsTypeNum)
+	})
 	if err != nil {
-		return formatError(err)
+		return "", y.playerError(ctx, err, playerName)
 	}
-	return formatPlayerStats(p)
+	return formatPlayerStats(p), nil
 }
 
 func formatStatsDiff(diff *yflib.StatsDiff) string {
@@ -249,29 +433,36 @@ func formatStatsDiff(diff *yflib.StatsDiff) string {
 }
 
 // Compare computes the difference in stats between the two provided players.
-func (y *Yahoo) Compare(statsType, playerA, playerB string) string {
+func (y *Yahoo) Compare(ctx context.Context, statsType, playerA, playerB string) (string, error) {
 	statsTypeNum, err := convertStatsType(statsType)
 	if err != nil {
-		return formatError(err)
+		return "", err
 	}
 
-	diff, err := yflib.ComparePlayers(y.client, y.leagueKey, playerA, playerB, statsTypeNum, yflib.NBA9CATIDs)
+	key := fmt.Sprintf("compare:%d:%s:%s", statsTypeNum, strings.ToLower(playerA), strings.ToLower(playerB))
+	diff, err := cached(ctx, y.cache, key, ttlPlayers, func() (*yflib.StatsDiff, error) {
+		return yflib.ComparePlayers(y.clientFor(ctx), y.leagueKey, playerA, playerB, statsTypeNum, yflib.NBA9CATIDs)
+	})
 	if err != nil {
-		return formatError(err)
+		return "", y.playerError(ctx, err, playerA, playerB)
 	}
 
-	return formatStatsDiff(diff)
+	return formatStatsDiff(diff), nil
 }
 
-func formatFreeAgents(freeAgents map[string][]*schema.Player) string {
+func formatFreeAgents(stats []string, freeAgents [][]*schema.Player, errs []error) string {
 	var out strings.Builder
 
 	out.WriteString("```")
 	out.WriteString("\n")
-	for stat, players := range freeAgents {
+	for i, stat := range stats {
 		out.WriteString(stat)
 		out.WriteString(fmt.Sprintf("\n%s\n", strings.Repeat("-", 20)))
-		for _, player := range players {
+		if errs[i] != nil {
+			out.WriteString(fmt.Sprintf("Error: %s\n\n\n", itemError(errs[i])))
+			continue
+		}
+		for _, player := range freeAgents[i] {
 			out.WriteString(player.Name.Full)
 			for _, s := range player.PlayerStats.Stats.Stat {
 				if yflib.StatIDToName[s.StatID] == stat {
@@ -288,22 +479,28 @@ func formatFreeAgents(freeAgents map[string][]*schema.Player) string {
 }
 
 // AnalyzeFreeAgents prints the top 5 players for the given stats with th given type.
-func (y *Yahoo) AnalyzeFreeAgents(statsType string, stats []string) string {
+func (y *Yahoo) AnalyzeFreeAgents(ctx context.Context, statsType string, stats []string) (string, error) {
 	statsTypeNum, err := convertStatsType(statsType)
 	if err != nil {
-		return formatError(err)
+		return "", err
 	}
 
-	freeAgents := make(map[string][]*schema.Player)
-	for _, stat := range stats {
-		players, err := yflib.SortFreeAgentsByStat(y.client, y.leagueKey, yflib.StatNameToID[strings.ToUpper(stat)], 5, statsTypeNum)
-		if err != nil {
-			return formatError(err)
-		}
-
-		freeAgents[strings.ToUpper(stat)] = players
+	names := make([]string, len(stats))
+	for i, stat := range stats {
+		names[i] = strings.ToUpper(strings.TrimSpace(stat))
 	}
-	return formatFreeAgents(freeAgents)
+
+	freeAgents, errs := fanOut(names, func(stat string) ([]*schema.Player, error) {
+		statID, ok := yflib.StatNameToID[stat]
+		if !ok {
+			return nil, &Error{Kind: ErrInvalidArgs, Subject: stat, Err: errors.New("unknown stat")}
+		}
+		key := fmt.Sprintf("freeagents:%d:%d", statsTypeNum, statID)
+		return cached(ctx, y.cache, key, ttlPlayers, func() ([]*schema.Player, error) {
+			return yflib.SortFreeAgentsByStat(y.clientFor(ctx), y.leagueKey, statID, 5, statsTypeNum)
+		})
+	})
+	return formatFreeAgents(names, freeAgents, errs), nil
 }
 
 func formatCategoryMatchupResults(results []yflib.CategoryMatchupResult) string {
@@ -339,19 +536,28 @@ func formatCategoryMatchupResults(results []yflib.CategoryMatchupResult) string
 }
 
 // VsLeague computes the given teams matchup outcome against every other team in the league.
-func (y *Yahoo) VsLeague(teamName string, week int) string {
-	results, err := yflib.CalculateCategoryMathchupResultsVsLeague(y.client, y.leagueKey, teamName, yflib.NBA9CATIDs, week)
+func (y *Yahoo) VsLeague(ctx context.Context, teamName string, week int) (string, error) {
+	if err := y.checkWeek(ctx, week); err != nil {
+		return "", err
+	}
+
+	key := fmt.Sprintf("vs:%d:%s", week, strings.ToLower(teamName))
+	results, err := cached(ctx, y.cache, key, ttlTeamStats, func() ([]yflib.CategoryMatchupResult, error) {
+		return yflib.CalculateCategoryMathchupResultsVsLeague(y.clientFor(ctx), y.leagueKey, teamName, yflib.NBA9CATIDs, week)
+	})
 	if err != nil {
-		return formatError(err)
+		return "", y.teamError(ctx, err, teamName)
 	}
-	return formatCategoryMatchupResults(results)
+	return formatCategoryMatchupResults(results), nil
 }
 
 // Schedule returns the season schedule for the given team.
-func (y *Yahoo) Schedule(teamName string) string {
-	tm, err := yflib.GetTeamMatchups(y.client, y.leagueKey, teamName)
+func (y *Yahoo) Schedule(ctx context.Context, teamName string) (string, error) {
+	tm, err := cached(ctx, y.cache, "schedule:"+strings.ToLower(teamName), ttlSchedule, func() (*schema.Team, error) {
+		return yflib.GetTeamMatchups(y.clientFor(ctx), y.leagueKey, teamName)
+	})
 	if err != nil {
-		return formatError(err)
+		return "", y.teamError(ctx, err, teamName)
 	}
 
 	var out strings.Builder
@@ -389,24 +595,26 @@ func (y *Yahoo) Schedule(teamName string) string {
 	out.WriteString(fmt.Sprintf("\nTotal: %d-%d-%d", win, loss, tie))
 	out.WriteString("```")
 
-	return out.String()
+	return out.String(), nil
 }
 
 // Owner returns the owner for all the provided players.
-func (y *Yahoo) Owner(playerNames []string) string {
-	players := []*schema.Player{}
-	for _, name := range playerNames {
-		player, err := yflib.GetPlayerOwnership(y.client, y.leagueKey, name)
-		if err != nil {
-			return formatError(err)
-		}
-		players = append(players, player)
-	}
+func (y *Yahoo) Owner(ctx context.Context, playerNames []string) (string, error) {
+	players, errs := fanOut(playerNames, func(name string) (*schema.Player, error) {
+		return cached(ctx, y.cache, "owner:"+strings.ToLower(name), ttlRosters, func() (*schema.Player, error) {
+			return yflib.GetPlayerOwnership(y.clientFor(ctx), y.leagueKey, name)
+		})
+	})
 
 	var out strings.Builder
 	out.WriteString("```")
 
-	for _, player := range players {
+	for i, player := range players {
+		if errs[i] != nil {
+			out.WriteString(fmt.Sprintf("%s: Error: %s\n\n", playerNames[i], itemError(errs[i])))
+			continue
+		}
+
 		out.WriteString(fmt.Sprintf("%s: ", player.Name.Full))
 		switch player.Ownership.OwnershipType {
 		case "freeagents":
@@ -420,23 +628,35 @@ func (y *Yahoo) Owner(playerNames []string) string {
 		out.WriteString("\n\n")
 	}
 	out.WriteString("```")
-	return out.String()
+	return out.String(), nil
 }
 
-// Leaders returns the stat category leaders for a given day.
-func (y *Yahoo) Leaders(date string) string {
+// Leaders returns the stat category leaders for a given day. Categories that
+// fail to load are shown with their error, unless they all fail, in which case
+// the error is returned instead.
+func (y *Yahoo) Leaders(ctx context.Context, date string) (string, error) {
+	pst, _ := time.LoadLocation("America/Los_Angeles")
+	today := time.Now().In(pst).Format("2006-01-02")
 	if date == "yesterday" {
-		pst, _ := time.LoadLocation("America/Los_Angeles")
 		date = time.Now().In(pst).AddDate(0, 0, -1).Format("2006-01-02")
 	}
 
-	leaders := make(map[int][]schema.Player)
-	for stat := range statIDs9CAT {
-		players, err := yflib.StatCategoryLeaders(y.client, date, y.gameKey, stat, 5)
-		if err != nil {
-			return formatError(err)
-		}
-		leaders[stat] = append(leaders[stat], players...)
+	// Leaders from previous days are final, so they can be kept for longer.
+	ttl := ttlLeaders
+	if date < today {
+		ttl = ttlFinal
+	}
+
+	leaders, errs := fanOut(orderedStats9CAT, func(stat int) ([]schema.Player, error) {
+		return cached(ctx, y.cache, fmt.Sprintf("leaders:%s:%d", date, stat), ttl, func() ([]schema.Player, error) {
+			return yflib.StatCategoryLeaders(y.clientFor(ctx), date, y.gameKey, stat, 5)
+		})
+	})
+	if allFailed(errs) {
+		return "", classify(errs[0])
+	}
+	if !anyStats(leaders, errs) {
+		return "", &Error{Kind: ErrNoGames, Subject: date}
 	}
 
 	var out strings.Builder
@@ -445,11 +665,14 @@ func (y *Yahoo) Leaders(date string) string {
 	out.WriteString(header)
 	out.WriteString(strings.Repeat("-", len(header)))
 	out.WriteString("\n\n")
-	for _, stat := range orderedStats9CAT {
-		players := leaders[stat]
+	for i, stat := range orderedStats9CAT {
 		out.WriteString(yflib.StatIDToName[stat] + "\n")
 		out.WriteString(strings.Repeat("-", 25) + "\n")
-		for _, p := range players {
+		if errs[i] != nil {
+			out.WriteString(fmt.Sprintf("Error: %s\n\n", itemError(errs[i])))
+			continue
+		}
+		for _, p := range leaders[i] {
 			out.WriteString(fmt.Sprintf("%s - %s", p.Name.Full, p.DisplayPosition))
 			for _, s := range p.PlayerStats.Stats.Stat {
 				if s.StatID == stat {
@@ -460,20 +683,68 @@ func (y *Yahoo) Leaders(date string) string {
 		out.WriteString("\n")
 	}
 	out.WriteString("```")
-	return out.String()
+	return out.String(), nil
+}
+
+// allFailed reports whether every one of errs is an error.
+func allFailed(errs []error) bool {
+	for _, err := range errs {
+		if err == nil {
+			return false
+		}
+	}
+	return len(errs) > 0
+}
+
+// anyStats reports whether any of the leaders recorded a stat, which they
+// don't on days without games. Categories that failed to load are assumed to
+// have stats, so that their errors are shown.
+func anyStats(leaders [][]schema.Player, errs []error) bool {
+	for i, players := range leaders {
+		if errs[i] != nil {
+			return true
+		}
+		for _, p := range players {
+			if p.PlayerStats == nil || p.PlayerStats.Stats == nil {
+				continue
+			}
+			for _, s := range p.PlayerStats.Stats.Stat {
+				if s.Value != "" && s.Value != "-" && s.Value != "0" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// teamStats returns the stats of every team in the league for the given week.
+// If week is 0, the current week is used.
+func (y *Yahoo) teamStats(ctx context.Context, week int) (*schema.Teams, error) {
+	return cached(ctx, y.cache, fmt.Sprintf("teamstats:%d", week), ttlTeamStats, func() (*schema.Teams, error) {
+		fc, err := yfquery.League().Key(y.leagueKey).Teams().Stats().Week(week).Get(y.clientFor(ctx))
+		if err != nil {
+			return nil, err
+		}
+		return fc.League.Teams, nil
+	})
 }
 
 // HeadToHead displays the matchup results between the two given teams on the given week.
-func (y *Yahoo) HeadToHead(week int, teamA, teamB string) string {
-	allTeams, err := yfquery.League().Key(y.leagueKey).Teams().Stats().Week(week).Get(y.client)
+func (y *Yahoo) HeadToHead(ctx context.Context, week int, teamA, teamB string) (string, error) {
+	if err := y.checkWeek(ctx, week); err != nil {
+		return "", err
+	}
+
+	allTeams, err := y.teamStats(ctx, week)
 	if err != nil {
-		return formatError(err)
+		return "", classify(err)
 	}
 
 	var teamAStats *schema.TeamStats
 	var teamBStats *schema.TeamStats
 
-	for _, tm := range allTeams.League.Teams.Team {
+	for _, tm := range allTeams.Team {
 		if tm.Name == teamA {
 			teamAStats = tm.TeamStats
 			continue
@@ -486,10 +757,10 @@ func (y *Yahoo) HeadToHead(week int, teamA, teamB string) string {
 	}
 
 	if teamAStats == nil {
-		return formatError(fmt.Errorf("%q team not found", teamA))
+		return "", y.teamError(ctx, fmt.Errorf("team %q not found", teamA), teamA)
 	}
 	if teamBStats == nil {
-		return formatError(fmt.Errorf("%q team not found", teamB))
+		return "", y.teamError(ctx, fmt.Errorf("team %q not found", teamB), teamB)
 	}
 
 	var out strings.Builder
@@ -534,11 +805,13 @@ func (y *Yahoo) HeadToHead(week int, teamA, teamB string) string {
 	out.WriteString(fmt.Sprintf("\nTotal: %d-%d-%d", w, l, t))
 	out.WriteString("```")
 
-	return out.String()
+	return out.String(), nil
 }
 
 func sortTeamsByStat(tms *schema.Teams, statID int) []schema.Team {
-	teams := tms.Team
+	// Sort a copy, since tms may be shared through the cache.
+	teams := make([]schema.Team, len(tms.Team))
+	copy(teams, tms.Team)
 	sort.Slice(teams, func(i, j int) bool {
 		for k, stat := range teams[i].TeamStats.Stats.Stat {
 			if stat.StatID != statID {
@@ -560,16 +833,20 @@ func sortTeamsByStat(tms *schema.Teams, statID int) []schema.Team {
 
 // Ranks sorts all the teams by the given stat for the given week and returns
 // the sorted list as a string. If no week is given, the current week is used.
-func (y *Yahoo) Ranks(week int, stat string) string {
+func (y *Yahoo) Ranks(ctx context.Context, week int, stat string) (string, error) {
 	if _, found := yflib.StatNameToID[strings.ToUpper(stat)]; !found {
-		return formatError(fmt.Errorf("stat %q not found", stat))
+		return "", &Error{Kind: ErrInvalidArgs, Subject: stat, Err: errors.New("unknown stat")}
+	}
+	if err := y.checkWeek(ctx, week); err != nil {
+		return "", err
 	}
-	allTeams, err := yfquery.League().Key(y.leagueKey).Teams().Stats().Week(week).Get(y.client)
+
+	allTeams, err := y.teamStats(ctx, week)
 	if err != nil {
-		return formatError(err)
+		return "", classify(err)
 	}
 
-	sortedTms := sortTeamsByStat(allTeams.League.Teams, yflib.StatNameToID[strings.ToUpper(stat)])
+	sortedTms := sortTeamsByStat(allTeams, yflib.StatNameToID[strings.ToUpper(stat)])
 
 	var out strings.Builder
 	out.WriteString("```\n")
@@ -583,7 +860,48 @@ func (y *Yahoo) Ranks(week int, stat string) string {
 		out.WriteString(fmt.Sprintf("%2d: %s - %s\n", i+1, tm.Name, val))
 	}
 	out.WriteString("```")
-	return out.String()
+	return out.String(), nil
+}
+
+// LastSuccess returns when Yahoo last responded successfully, or the zero time
+// if it hasn't yet.
+func (y *Yahoo) LastSuccess() time.Time {
+	return y.transport.lastSuccess()
+}
+
+// TokenExpiry returns when the Yahoo access token expires. The token is
+// refreshed first if it has already expired, so an error means the bot can no
+// longer authenticate with Yahoo. The token source can't be cancelled, so a
+// refresh that outlives ctx is left to finish in the background.
+func (y *Yahoo) TokenExpiry(ctx context.Context) (time.Time, error) {
+	if y.tokens == nil {
+		return time.Time{}, &Error{Kind: ErrAuthExpired, Err: errors.New("no token source")}
+	}
+
+	type result struct {
+		tok *oauth2.Token
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		tok, err := y.tokens.Token()
+		done <- result{tok, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return time.Time{}, classify(r.err)
+		}
+		return r.tok.Expiry, nil
+	case <-ctx.Done():
+		return time.Time{}, ctx.Err()
+	}
+}
+
+// FlushCache removes all cached Yahoo responses.
+func (y *Yahoo) FlushCache() string {
+	return fmt.Sprintf("```Flushed %d cached responses.```", y.cache.flush())
 }
 
 // Help returns the help docs.
@@ -655,5 +973,55 @@ func (y *Yahoo) Help() *discordgo.MessageEmbed {
 			Name:  "!ranks [week] <stat>",
 			Value: "Returns the team ranking for the given stat for the given week. If no week is provided, the current week is used.",
 		})
+	embed.Fields = append(embed.Fields,
+		&discordgo.MessageEmbedField{
+			Name:  "!recap [week]",
+			Value: "Returns a recap of the given week: every matchup's result, the biggest blowout, the closest matchup, the best team in each category and the standings. If no week is provided, the last completed week is used.",
+		})
+	embed.Fields = append(embed.Fields,
+		&discordgo.MessageEmbedField{
+			Name:  "!preview [week]",
+			Value: "Returns a preview of every matchup of the given week: the games each team has scheduled, their season category averages and the projected winner of each category. If no week is provided, the current week is used.",
+		})
+	embed.Fields = append(embed.Fields,
+		&discordgo.MessageEmbedField{
+			Name:  "!flush",
+			Value: "Clears the cached Yahoo responses. By default, admins and the commissioner only.",
+		})
+	embed.Fields = append(embed.Fields,
+		&discordgo.MessageEmbedField{
+			Name:  "!prefix [prefix|reset]",
+			Value: "Shows the command prefix. Admins and the commissioner can change it for the server, or reset it to the configured one. Commands can also be run by mentioning the bot, e.g. @bot standings.",
+		})
+	embed.Fields = append(embed.Fields,
+		&discordgo.MessageEmbedField{
+			Name:  "!leagues",
+			Value: "Lists the leagues of the bot's Yahoo account across all games and seasons, with their keys, scoring types and team counts. By default, admins and the commissioner only.",
+		})
+	embed.Fields = append(embed.Fields,
+		&discordgo.MessageEmbedField{
+			Name:  "!link <team>",
+			Value: "Links you to your team as its owner, so that you are sent the changes to your players' injury status in a DM.",
+		})
+	embed.Fields = append(embed.Fields,
+		&discordgo.MessageEmbedField{
+			Name:  "!unlink [team]",
+			Value: "Unlinks you from your teams, or unlinks the given team. Admins and the commissioner can unlink any team.",
+		})
+	embed.Fields = append(embed.Fields,
+		&discordgo.MessageEmbedField{
+			Name:  "!schedule-add <schedule> <command>",
+			Value: "Runs the command in this channel on a cron schedule, e.g. !schedule-add 0 21 * * sun !standings. Times are in America/Los_Angeles unless the schedule starts with TZ=<zone>. By default, admins and the commissioner only.",
+		})
+	embed.Fields = append(embed.Fields,
+		&discordgo.MessageEmbedField{
+			Name:  "!schedule-list",
+			Value: "Lists the scheduled commands of the server. By default, admins and the commissioner only.",
+		})
+	embed.Fields = append(embed.Fields,
+		&discordgo.MessageEmbedField{
+			Name:  "!schedule-remove <id>",
+			Value: "Removes the scheduled command with the given ID. By default, admins and the commissioner only.",
+		})
 	return embed
 }
@@ -0,0 +1,221 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxCronSearch is how far ahead Cron.Next looks for a matching time, which is
+// long enough to find e.g. the next February 29th.
+const maxCronSearch = 5 * 366 * 24 * time.Hour
+
+// cronDescriptors are the shorthands accepted in place of the five fields.
+var cronDescriptors = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+}
+
+var (
+	monthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	dayNames   = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+// cronField describes the values a field of a cron expression can take.
+type cronField struct {
+	name     string
+	min, max int
+	// names are the names of the values from min, if any.
+	names []string
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: monthNames},
+	// 7 is also accepted for Sunday.
+	{name: "day of week", min: 0, max: 7, names: dayNames},
+}
+
+// Cron runs at the times matching a cron expression.
+type Cron struct {
+	minute, hour, dom, month, dow uint64
+	// domAny and dowAny are set if the day fields start with "*", e.g. "*"
+	// or "*/2". If neither is, a day matches if either field matches,
+	// otherwise both must.
+	domAny, dowAny bool
+	Location       *time.Location
+}
+
+// ParseCron returns the schedule of a standard cron expression with the five
+// fields minute, hour, day of month, month and day of week, e.g. "0 21 * * sun".
+// Fields can be lists, ranges and steps, e.g. "1,15", "mon-fri" or "*/15",
+// and the expression can be a shorthand such as "@daily". It can start with
+// "TZ=<zone>" to run in the IANA time zone, otherwise it runs in loc.
+func ParseCron(expr string, loc *time.Location) (Cron, error) {
+	fields := strings.Fields(expr)
+	if len(fields) > 0 {
+		if tz, ok := strings.CutPrefix(fields[0], "TZ="); ok {
+			l, err := time.LoadLocation(tz)
+			if err != nil || tz == "" {
+				return Cron{}, fmt.Errorf("unknown timezone %q", tz)
+			}
+			loc, fields = l, fields[1:]
+		}
+	}
+	if len(fields) == 1 && strings.HasPrefix(fields[0], "@") {
+		spec, ok := cronDescriptors[strings.ToLower(fields[0])]
+		if !ok {
+			return Cron{}, fmt.Errorf("unknown schedule %q", fields[0])
+		}
+		fields = strings.Fields(spec)
+	}
+	if len(fields) != len(cronFields) {
+		return Cron{}, fmt.Errorf("schedule %q must have 5 fields: minute, hour, day of month, month and day of week", expr)
+	}
+
+	var sets [5]uint64
+	for i, f := range cronFields {
+		set, err := f.parse(fields[i])
+		if err != nil {
+			return Cron{}, err
+		}
+		sets[i] = set
+	}
+
+	// Sunday can be 0 or 7.
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+	c := Cron{
+		minute: sets[0], hour: sets[1], dom: sets[2], month: sets[3], dow: sets[4],
+		domAny:   strings.HasPrefix(fields[2], "*"),
+		dowAny:   strings.HasPrefix(fields[4], "*"),
+		Location: loc,
+	}
+	if c.Next(time.Now()).IsZero() {
+		return Cron{}, fmt.Errorf("schedule %q never runs", expr)
+	}
+	return c, nil
+}
+
+// parse returns the set of values of the field in s as a bitset.
+func (f cronField) parse(s string) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(s, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepStr); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q in %s", stepStr, f.name)
+			}
+		}
+
+		lo, hi := f.min, f.max
+		if rng != "*" {
+			loStr, hiStr, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = f.value(loStr); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = f.value(hiStr); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				hi = f.max
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("invalid range %q in %s", rng, f.name)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+// value returns the value of a single number or name in the field.
+func (f cronField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid %s %q", f.name, s)
+	}
+	return v, nil
+}
+
+// dayMatches reports whether the schedule runs on the day of t.
+func (c Cron) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<t.Day()) != 0
+	dow := c.dow&(1<<t.Weekday()) != 0
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Next implements Schedule. It returns the zero time if the schedule doesn't
+// run in the next five years. Times that are skipped when clocks spring forward
+// don't run that day, and times that repeat when clocks fall back only run the
+// first time.
+func (c Cron) Next(t time.Time) time.Time {
+	from := t.In(c.Location)
+	limit := from.Add(maxCronSearch)
+	t = time.Date(from.Year(), from.Month(), from.Day(), from.Hour(), from.Minute()+1, 0, 0, c.Location)
+
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<t.Month()) == 0:
+			t = forward(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, c.Location))
+		case !c.dayMatches(t):
+			t = forward(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, c.Location))
+		case c.hour&(1<<t.Hour()) == 0:
+			t = forward(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, c.Location))
+		case c.minute&(1<<t.Minute()) == 0, !wallAfter(t, from):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// forward returns u if it is after t, or else the next minute after t. The
+// times time.Date returns for wall clock times that are skipped or repeated
+// when clocks change can be before the time they were computed from.
+func forward(t, u time.Time) time.Time {
+	if u.After(t) {
+		return u
+	}
+	return t.Truncate(time.Minute).Add(time.Minute)
+}
+
+// wallAfter reports whether the wall clock time of t, to the minute, is after
+// that of u. They differ from the instants when clocks fall back.
+func wallAfter(t, u time.Time) bool {
+	ty, tm, td := t.Date()
+	uy, um, ud := u.Date()
+	a := []int{ty, int(tm), td, t.Hour(), t.Minute()}
+	b := []int{uy, int(um), ud, u.Hour(), u.Minute()}
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] > b[i]
+		}
+	}
+	return false
+}
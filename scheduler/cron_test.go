@@ -0,0 +1,183 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	la, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(s string) time.Time {
+		t.Helper()
+		tm, err := time.ParseInLocation("2006-01-02 15:04 MST", s, la)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		from string
+		want []string
+	}{
+		{
+			name: "every minute",
+			expr: "* * * * *",
+			from: "2026-01-05 10:07 PST",
+			want: []string{"2026-01-05 10:08 PST", "2026-01-05 10:09 PST"},
+		},
+		{
+			name: "minute step",
+			expr: "*/15 * * * *",
+			from: "2026-01-05 10:07 PST",
+			want: []string{"2026-01-05 10:15 PST", "2026-01-05 10:30 PST", "2026-01-05 10:45 PST", "2026-01-05 11:00 PST"},
+		},
+		{
+			name: "range step",
+			expr: "0 9-17/4 * * *",
+			from: "2026-01-05 10:00 PST",
+			want: []string{"2026-01-05 13:00 PST", "2026-01-05 17:00 PST", "2026-01-06 09:00 PST"},
+		},
+		{
+			name: "list",
+			expr: "0 8 1,15 * *",
+			from: "2026-01-05 10:00 PST",
+			want: []string{"2026-01-15 08:00 PST", "2026-02-01 08:00 PST"},
+		},
+		{
+			name: "day names",
+			expr: "0 9 * * mon-fri",
+			from: "2026-01-09 10:00 PST", // Friday
+			want: []string{"2026-01-12 09:00 PST", "2026-01-13 09:00 PST"},
+		},
+		{
+			name: "month names",
+			expr: "0 0 1 JAN,jul *",
+			from: "2026-01-05 10:00 PST",
+			want: []string{"2026-07-01 00:00 PDT", "2027-01-01 00:00 PST"},
+		},
+		{
+			name: "seven is sunday",
+			expr: "0 21 * * 7",
+			from: "2026-01-05 10:00 PST",
+			want: []string{"2026-01-11 21:00 PST", "2026-01-18 21:00 PST"},
+		},
+		{
+			name: "sunday range to seven",
+			expr: "0 21 * * 5-7",
+			from: "2026-01-05 10:00 PST",
+			want: []string{"2026-01-09 21:00 PST", "2026-01-10 21:00 PST", "2026-01-11 21:00 PST", "2026-01-16 21:00 PST"},
+		},
+		{
+			name: "day of month or day of week",
+			expr: "0 0 13 * fri",
+			from: "2026-01-05 10:00 PST",
+			want: []string{"2026-01-09 00:00 PST", "2026-01-13 00:00 PST", "2026-01-16 00:00 PST"},
+		},
+		{
+			name: "stepped day of week restricts day of month",
+			expr: "0 0 1-7 * */2",
+			from: "2026-02-01 10:00 PST", // Sunday
+			want: []string{"2026-02-03 00:00 PST", "2026-02-05 00:00 PST", "2026-02-07 00:00 PST", "2026-03-01 00:00 PST"},
+		},
+		{
+			name: "stepped day of month restricts day of week",
+			expr: "0 0 */10 * mon",
+			from: "2026-01-01 10:00 PST",
+			want: []string{"2026-05-11 00:00 PDT", "2026-06-01 00:00 PDT"},
+		},
+		{
+			name: "hourly",
+			expr: "@hourly",
+			from: "2026-01-05 10:07 PST",
+			want: []string{"2026-01-05 11:00 PST", "2026-01-05 12:00 PST"},
+		},
+		{
+			name: "weekly",
+			expr: "@weekly",
+			from: "2026-01-05 10:00 PST",
+			want: []string{"2026-01-11 00:00 PST", "2026-01-18 00:00 PST"},
+		},
+		{
+			name: "yearly",
+			expr: "@Annually",
+			from: "2026-01-05 10:00 PST",
+			want: []string{"2027-01-01 00:00 PST"},
+		},
+		{
+			name: "february 29",
+			expr: "0 12 29 feb *",
+			from: "2025-03-01 00:00 PST",
+			want: []string{"2028-02-29 12:00 PST", "2032-02-29 12:00 PST"},
+		},
+		{
+			name: "skipped when clocks spring forward",
+			expr: "30 2 * * *",
+			from: "2026-03-07 03:00 PST",
+			want: []string{"2026-03-09 02:30 PDT", "2026-03-10 02:30 PDT"},
+		},
+		{
+			name: "after clocks spring forward",
+			expr: "0 * * * *",
+			from: "2026-03-08 00:30 PST",
+			want: []string{"2026-03-08 01:00 PST", "2026-03-08 03:00 PDT", "2026-03-08 04:00 PDT"},
+		},
+		{
+			name: "once when clocks fall back",
+			expr: "30 1 * * *",
+			from: "2026-11-01 00:00 PDT",
+			want: []string{"2026-11-01 01:30 PDT", "2026-11-02 01:30 PST"},
+		},
+		{
+			name: "timezone",
+			expr: "TZ=America/New_York 0 9 * * *",
+			from: "2026-01-05 07:00 PST",
+			want: []string{"2026-01-06 06:00 PST", "2026-01-07 06:00 PST"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCron(tt.expr, la)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+			}
+			next := at(tt.from)
+			for _, w := range tt.want {
+				next = c.Next(next)
+				if want := at(w); !next.Equal(want) {
+					t.Fatalf("Next = %s, want %s", next.In(la).Format(time.RFC3339), want.Format(time.RFC3339))
+				}
+			}
+		})
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"* * * * sunday",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"@fortnightly",
+		"TZ=Nowhere/Special 0 9 * * *",
+		"0 0 30 feb *",
+		"0 0 31 apr,jun *",
+	} {
+		if _, err := ParseCron(expr, time.UTC); err == nil {
+			t.Errorf("ParseCron(%q) succeeded, want an error", expr)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+// Package scheduler runs jobs, such as posts to Discord, on a schedule.
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/famendola1/fantasy-discord-bot/metrics"
+)
+
+// jobTimeout is the maximum time a job may take.
+const jobTimeout = 2 * time.Minute
+
+// Schedule decides when a job runs.
+type Schedule interface {
+	// Next returns the first time after t that the job runs, or the zero
+	// time if it doesn't run again.
+	Next(t time.Time) time.Time
+}
+
+// Job is work done on a schedule.
+type Job struct {
+	// Name identifies the job in logs and metrics.
+	Name string
+	// ID identifies the job among the running jobs, if Name doesn't, e.g.
+	// because several jobs share a name. Defaults to Name.
+	ID       string
+	Schedule Schedule
+	Run      func(ctx context.Context) error
+}
+
+// Scheduler runs jobs when they are due. Jobs are set in groups, so that the
+// jobs from different sources, e.g. the config file and commands, can be
+// replaced independently. It is safe for concurrent use.
+type Scheduler struct {
+	mu      sync.Mutex
+	groups  map[string][]Job
+	changed chan struct{}
+	// running holds the IDs of the jobs that are running, so that a job
+	// isn't run again while its previous run is still in flight.
+	running map[string]bool
+}
+
+// New returns a Scheduler without jobs.
+func New() *Scheduler {
+	return &Scheduler{
+		groups:  make(map[string][]Job),
+		changed: make(chan struct{}, 1),
+		running: make(map[string]bool),
+	}
+}
+
+// Set replaces the jobs of the group.
+func (s *Scheduler) Set(group string, jobs []Job) {
+	s.mu.Lock()
+	s.groups[group] = jobs
+	s.mu.Unlock()
+
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+// due returns the jobs that run after last and no later than now, and the
+// next time after now that a job runs.
+func (s *Scheduler) due(last, now time.Time) ([]Job, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var jobs []Job
+	var next time.Time
+	for _, group := range s.groups {
+		for _, job := range group {
+			t := job.Schedule.Next(last)
+			if t.IsZero() {
+				continue
+			}
+			if !t.After(now) {
+				jobs = append(jobs, job)
+				t = job.Schedule.Next(now)
+			}
+			if !t.IsZero() && (next.IsZero() || t.Before(next)) {
+				next = t
+			}
+		}
+	}
+	return jobs, next
+}
+
+// Run runs the jobs until ctx is done. A job that is missed, e.g. because the
+// bot wasn't running or its previous run hasn't finished, isn't run late.
+func (s *Scheduler) Run(ctx context.Context) {
+	last := time.Now()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		now := time.Now()
+		jobs, next := s.due(last, now)
+		last = now
+		for _, job := range jobs {
+			go s.run(ctx, job)
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		if !next.IsZero() {
+			timer.Reset(next.Sub(now))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		case <-s.changed:
+		}
+	}
+}
+
+// start marks the job as running. It reports false if it already is.
+func (s *Scheduler) start(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running[id] {
+		return false
+	}
+	s.running[id] = true
+	return true
+}
+
+// done marks the job as no longer running.
+func (s *Scheduler) done(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.running, id)
+}
+
+// run runs the job, logging and recording its outcome. The job is skipped if
+// its previous run is still in flight.
+func (s *Scheduler) run(ctx context.Context, job Job) {
+	id := job.ID
+	if id == "" {
+		id = job.Name
+	}
+	if !s.start(id) {
+		slog.Warn("skipping scheduled job, its previous run hasn't finished", "job", job.Name)
+		metrics.ObserveJob(job.Name, "skipped")
+		return
+	}
+	defer s.done(id)
+
+	ctx, cancel := context.WithTimeout(ctx, jobTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := job.Run(ctx)
+	latency := time.Since(start)
+
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+		slog.Error("scheduled job failed", "job", job.Name, "latency", latency, "error", err)
+	} else {
+		slog.Info("scheduled job", "job", job.Name, "latency", latency)
+	}
+	metrics.ObserveJob(job.Name, outcome)
+}
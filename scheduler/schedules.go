@@ -0,0 +1,72 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Daily runs every day at a time of day.
+type Daily struct {
+	Hour, Minute int
+	Location     *time.Location
+}
+
+// ParseDaily returns a Daily schedule at clock, formatted as 15:04, in the
+// IANA time zone tz. tz defaults to UTC.
+func ParseDaily(clock, tz string) (Daily, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return Daily{}, fmt.Errorf("time %q must be formatted as HH:MM", clock)
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return Daily{}, fmt.Errorf("unknown timezone %q", tz)
+	}
+	return Daily{Hour: t.Hour(), Minute: t.Minute(), Location: loc}, nil
+}
+
+// Next implements Schedule.
+func (d Daily) Next(t time.Time) time.Time {
+	t = t.In(d.Location)
+	next := time.Date(t.Year(), t.Month(), t.Day(), d.Hour, d.Minute, 0, 0, d.Location)
+	if !next.After(t) {
+		next = time.Date(t.Year(), t.Month(), t.Day()+1, d.Hour, d.Minute, 0, 0, d.Location)
+	}
+	return next
+}
+
+// Interval runs repeatedly with the given time in between. Runs are aligned
+// to multiples of the interval, e.g. on the hour and every 5 minutes after.
+type Interval time.Duration
+
+// Next implements Schedule.
+func (i Interval) Next(t time.Time) time.Time {
+	return t.Truncate(time.Duration(i)).Add(time.Duration(i))
+}
+
+// Weekly runs every week on a day at a time of day.
+type Weekly struct {
+	Weekday time.Weekday
+	Daily
+}
+
+// ParseWeekday returns the day of the week named day, e.g. monday or mon.
+func ParseWeekday(day string) (time.Weekday, error) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if strings.EqualFold(day, name) || strings.EqualFold(day, name[:3]) {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown day %q", day)
+}
+
+// Next implements Schedule.
+func (w Weekly) Next(t time.Time) time.Time {
+	next := w.Daily.Next(t)
+	for next.Weekday() != w.Weekday {
+		next = w.Daily.Next(next)
+	}
+	return next
+}
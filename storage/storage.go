@@ -0,0 +1,159 @@
+// Package storage persists state that is changed while the bot runs, such as
+// settings changed with admin commands, so that it survives restarts.
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Store is a key-value store of JSON values, kept in memory and written to a
+// file after every change. A change that can't be written is undone. It is
+// safe for concurrent use.
+type Store struct {
+	path string
+
+	mu   sync.Mutex
+	data map[string]json.RawMessage
+}
+
+// Open returns a Store backed by the file at path, loading its contents if it
+// exists. If path is empty, the store is kept in memory only.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, data: make(map[string]json.RawMessage)}
+	if path == "" {
+		return s, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &s.data); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Get decodes the value of key into v. It reports whether the key exists.
+func (s *Store) Get(key string, v any) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	raw, ok := s.data[key]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(raw, v)
+}
+
+// Put sets the value of key to v.
+func (s *Store) Put(key string, v any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.put(key, v)
+}
+
+// Delete removes key. It reports whether the key existed.
+func (s *Store) Delete(key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.data[key]
+	if !ok {
+		return false, nil
+	}
+	delete(s.data, key)
+	if err := s.save(); err != nil {
+		s.data[key] = old
+		return true, err
+	}
+	return true, nil
+}
+
+// Update decodes the value of key into v, if it exists, calls fn to modify v
+// and stores the result. No other change is made to the store in between. If
+// fn returns an error, the value isn't stored.
+func (s *Store) Update(key string, v any, fn func() error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if raw, ok := s.data[key]; ok {
+		if err := json.Unmarshal(raw, v); err != nil {
+			return err
+		}
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	return s.put(key, v)
+}
+
+// Keys returns the keys that start with prefix, in order.
+func (s *Store) Keys(prefix string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var keys []string
+	for key := range s.data {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *Store) put(key string, v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	old, ok := s.data[key]
+	s.data[key] = raw
+	if err := s.save(); err != nil {
+		// Undo the change, so that the store matches its file.
+		if ok {
+			s.data[key] = old
+		} else {
+			delete(s.data, key)
+		}
+		return err
+	}
+	return nil
+}
+
+// save writes the store to its file. The file is replaced rather than written
+// in place, so a crash can't leave it half written.
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	content, err := json.MarshalIndent(s.data, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}